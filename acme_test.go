@@ -0,0 +1,369 @@
+package echo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// testACMEServer is a minimal RFC 8555 CA for tests. It only offers the
+// HTTP-01 challenge, which it validates by requesting the token from the
+// address the domain resolves to, and signs certificates with its own root.
+// Nonces and JWS signatures are not verified.
+type testACMEServer struct {
+	*httptest.Server
+
+	resolve map[string]string // domain to host:port serving HTTP-01 challenges
+	rootKey *ecdsa.PrivateKey
+	root    *x509.Certificate
+
+	mu         sync.Mutex
+	nonce      int
+	thumbprint string // of the account key
+	orders     []*testACMEOrder
+}
+
+type testACMEOrder struct {
+	domain string
+	token  string
+	status string // pending, ready or valid
+	authz  string // pending, valid or invalid
+	chain  []byte
+}
+
+func newTestACMEServer(resolve map[string]string) *testACMEServer {
+	ca := &testACMEServer{resolve: resolve}
+
+	var err error
+	if ca.rootKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		panic(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test ACME Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &ca.rootKey.PublicKey, ca.rootKey)
+	if err != nil {
+		panic(err)
+	}
+	if ca.root, err = x509.ParseCertificate(der); err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/directory", ca.handleDirectory)
+	mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {
+		ca.addNonce(w)
+	})
+	mux.HandleFunc("/account", ca.handleAccount)
+	mux.HandleFunc("/order", ca.handleNewOrder)
+	mux.HandleFunc("/order/", ca.handleOrder)
+	mux.HandleFunc("/authz/", ca.handleAuthz)
+	mux.HandleFunc("/challenge/", ca.handleChallenge)
+	mux.HandleFunc("/finalize/", ca.handleFinalize)
+	mux.HandleFunc("/cert/", ca.handleCert)
+	ca.Server = httptest.NewServer(mux)
+	return ca
+}
+
+// Roots returns a pool with the root certificate issued certificates chain to.
+func (ca *testACMEServer) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.root)
+	return pool
+}
+
+func (ca *testACMEServer) addNonce(w http.ResponseWriter) {
+	ca.mu.Lock()
+	ca.nonce++
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", ca.nonce))
+	ca.mu.Unlock()
+}
+
+func (ca *testACMEServer) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	ca.addNonce(w)
+	w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// readJWS decodes the payload of the JWS posted in r into v and returns its
+// protected header.
+func readJWS(r *http.Request, v interface{}) (protected map[string]json.RawMessage, err error) {
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+	}
+	if err = json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		return nil, err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &protected); err != nil {
+		return nil, err
+	}
+	if v == nil || jws.Payload == "" { // POST-as-GET
+		return protected, nil
+	}
+	if b, err = base64.RawURLEncoding.DecodeString(jws.Payload); err != nil {
+		return nil, err
+	}
+	return protected, json.Unmarshal(b, v)
+}
+
+// order returns the order with the index found at the end of the request path.
+func (ca *testACMEServer) order(r *http.Request) (int, *testACMEOrder) {
+	var i int
+	if _, err := fmt.Sscanf(r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:], "%d", &i); err != nil {
+		return 0, nil
+	}
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if i < 0 || i >= len(ca.orders) {
+		return 0, nil
+	}
+	return i, ca.orders[i]
+}
+
+func (ca *testACMEServer) handleDirectory(w http.ResponseWriter, r *http.Request) {
+	ca.writeJSON(w, http.StatusOK, map[string]string{
+		"newNonce":   ca.URL + "/nonce",
+		"newAccount": ca.URL + "/account",
+		"newOrder":   ca.URL + "/order",
+		"revokeCert": ca.URL + "/revoke",
+		"keyChange":  ca.URL + "/key-change",
+	})
+}
+
+func (ca *testACMEServer) handleAccount(w http.ResponseWriter, r *http.Request) {
+	protected, err := readJWS(r, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var jwk struct {
+		X string `json:"x"`
+		Y string `json:"y"`
+	}
+	if err := json.Unmarshal(protected["jwk"], &jwk); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+	thumbprint, err := acme.JWKThumbprint(&ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ca.mu.Lock()
+	ca.thumbprint = thumbprint
+	ca.mu.Unlock()
+
+	w.Header().Set(HeaderLocation, ca.URL+"/account/1")
+	ca.writeJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+}
+
+func (ca *testACMEServer) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Identifiers []struct {
+			Value string `json:"value"`
+		} `json:"identifiers"`
+	}
+	if _, err := readJWS(r, &req); err != nil || len(req.Identifiers) != 1 {
+		http.Error(w, "one identifier is supported", http.StatusBadRequest)
+		return
+	}
+	ca.mu.Lock()
+	i := len(ca.orders)
+	ca.orders = append(ca.orders, &testACMEOrder{
+		domain: req.Identifiers[0].Value,
+		token:  fmt.Sprintf("token-%d", i),
+		status: "pending",
+		authz:  "pending",
+	})
+	ca.mu.Unlock()
+	ca.writeOrder(w, http.StatusCreated, i)
+}
+
+func (ca *testACMEServer) handleOrder(w http.ResponseWriter, r *http.Request) {
+	i, o := ca.order(r)
+	if o == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ca.writeOrder(w, http.StatusOK, i)
+}
+
+func (ca *testACMEServer) writeOrder(w http.ResponseWriter, code, i int) {
+	ca.mu.Lock()
+	o := ca.orders[i]
+	v := map[string]interface{}{
+		"status":         o.status,
+		"identifiers":    []map[string]string{{"type": "dns", "value": o.domain}},
+		"authorizations": []string{fmt.Sprintf("%s/authz/%d", ca.URL, i)},
+		"finalize":       fmt.Sprintf("%s/finalize/%d", ca.URL, i),
+	}
+	if o.status == "valid" {
+		v["certificate"] = fmt.Sprintf("%s/cert/%d", ca.URL, i)
+	}
+	ca.mu.Unlock()
+	w.Header().Set(HeaderLocation, fmt.Sprintf("%s/order/%d", ca.URL, i))
+	ca.writeJSON(w, code, v)
+}
+
+func (ca *testACMEServer) authzJSON(i int, o *testACMEOrder) map[string]interface{} {
+	return map[string]interface{}{
+		"status":     o.authz,
+		"identifier": map[string]string{"type": "dns", "value": o.domain},
+		"challenges": []map[string]string{ca.challengeJSON(i, o)},
+	}
+}
+
+func (ca *testACMEServer) challengeJSON(i int, o *testACMEOrder) map[string]string {
+	return map[string]string{
+		"type":   "http-01",
+		"url":    fmt.Sprintf("%s/challenge/%d", ca.URL, i),
+		"token":  o.token,
+		"status": o.authz,
+	}
+}
+
+func (ca *testACMEServer) handleAuthz(w http.ResponseWriter, r *http.Request) {
+	i, o := ca.order(r)
+	if o == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ca.mu.Lock()
+	v := ca.authzJSON(i, o)
+	ca.mu.Unlock()
+	ca.writeJSON(w, http.StatusOK, v)
+}
+
+// handleChallenge validates the HTTP-01 challenge before responding, so the
+// authorization is final when the client polls it.
+func (ca *testACMEServer) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	i, o := ca.order(r)
+	if o == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ca.mu.Lock()
+	domain, token, keyAuth := o.domain, o.token, o.token+"."+ca.thumbprint
+	ca.mu.Unlock()
+
+	status := "invalid"
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ca.resolve[domain]+acmeChallengePath+token, nil)
+	req.Host = domain
+	if res, err := http.DefaultClient.Do(req); err == nil {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK && string(body) == keyAuth {
+			status = "valid"
+		}
+	}
+
+	ca.mu.Lock()
+	o.authz = status
+	if status == "valid" {
+		o.status = "ready"
+	}
+	v := ca.challengeJSON(i, o)
+	ca.mu.Unlock()
+	ca.writeJSON(w, http.StatusOK, v)
+}
+
+func (ca *testACMEServer) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	i, o := ca.order(r)
+	if o == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var req struct {
+		CSR string `json:"csr"`
+	}
+	if _, err := readJWS(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ca.mu.Lock()
+	domain, ready := o.domain, o.status == "ready"
+	ca.mu.Unlock()
+	if !ready {
+		http.Error(w, "order not ready", http.StatusForbidden)
+		return
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(i) + 2),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, ca.root, csr.PublicKey, ca.rootKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ca.mu.Lock()
+	o.chain = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.root.Raw})...)
+	o.status = "valid"
+	ca.mu.Unlock()
+	ca.writeOrder(w, http.StatusOK, i)
+}
+
+func (ca *testACMEServer) handleCert(w http.ResponseWriter, r *http.Request) {
+	_, o := ca.order(r)
+	if o == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ca.mu.Lock()
+	chain := o.chain
+	ca.mu.Unlock()
+	ca.addNonce(w)
+	w.Header().Set(HeaderContentType, "application/pem-certificate-chain")
+	w.Write(chain)
+}
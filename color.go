@@ -0,0 +1,77 @@
+package echo
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// colorer prints the startup banner. Text is colored with ANSI escape codes
+// only when the output is a terminal and the NO_COLOR environment variable is
+// not set, so redirected output stays plain.
+type colorer struct {
+	output  io.Writer
+	enabled bool
+}
+
+const (
+	colorRed   = "31"
+	colorGreen = "32"
+	colorBlue  = "34"
+)
+
+func newColorer() *colorer {
+	c := new(colorer)
+	c.SetOutput(os.Stdout)
+	return c
+}
+
+// SetOutput sets the output and enables colors when it is a terminal.
+func (c *colorer) SetOutput(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	c.output = w
+	c.enabled = isTerminal(w)
+}
+
+func (c *colorer) Output() io.Writer {
+	return c.output
+}
+
+func (c *colorer) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(c.output, format, args...)
+}
+
+func (c *colorer) Red(msg interface{}) string {
+	return c.color(colorRed, msg)
+}
+
+func (c *colorer) Green(msg interface{}) string {
+	return c.color(colorGreen, msg)
+}
+
+func (c *colorer) Blue(msg interface{}) string {
+	return c.color(colorBlue, msg)
+}
+
+func (c *colorer) color(code string, msg interface{}) string {
+	if !c.enabled {
+		return fmt.Sprint(msg)
+	}
+	return "\x1b[" + code + "m" + fmt.Sprint(msg) + "\x1b[0m"
+}
+
+// isTerminal reports whether w is a character device, e.g. a terminal rather
+// than a file or pipe, and colors are not disabled by the environment.
+func isTerminal(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
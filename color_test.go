@@ -0,0 +1,45 @@
+package echo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := newColorer()
+	c.SetOutput(buf)
+	assert.False(t, c.enabled)
+	assert.Equal(t, buf, c.Output())
+
+	c.Printf("%s %s %s", c.Red("r"), c.Green(1), c.Blue("b"))
+	assert.Equal(t, "r 1 b", buf.String())
+
+	c.enabled = true
+	assert.Equal(t, "\x1b[31mr\x1b[0m", c.Red("r"))
+	assert.Equal(t, "\x1b[32m1\x1b[0m", c.Green(1))
+	assert.Equal(t, "\x1b[34mb\x1b[0m", c.Blue("b"))
+
+	c.SetOutput(nil)
+	assert.NotPanics(t, func() { c.Printf("discarded") })
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := ioutil.TempFile("", "echo")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	assert.False(t, isTerminal(f))
+	assert.False(t, isTerminal(new(bytes.Buffer)))
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	assert.False(t, isTerminal(os.Stdout))
+}
@@ -2,8 +2,10 @@ package echo
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 type (
@@ -12,7 +14,7 @@ type (
 		Request() *http.Request
 
 		// SetRequest sets `*http.Request`.
-		// 	SetRequest(r *http.Request)
+		SetRequest(r *http.Request)
 
 		// SetResponse sets `*Response`.
 		// 	SetResponse(r *Response)
@@ -21,18 +23,18 @@ type (
 		Response() *Response
 
 		// IsTLS returns true if HTTP connection is TLS otherwise false.
-		// 	IsTLS() bool
+		IsTLS() bool
 
 		// IsWebSocket returns true if HTTP connection is WebSocket otherwise false.
 		// 	IsWebSocket() bool
 
 		// Scheme returns the HTTP protocol scheme, `http` or `https`.
-		// 	Scheme() string
+		Scheme() string
 
 		// RealIP returns the client's network address based on `X-Forwarded-For`
 		// or `X-Real-IP` request header.
 		// The behavior can be configured using `Echo#IPExtractor`.
-		// 	RealIP() string
+		RealIP() string
 
 		// Path returns the registered path for the handler.
 		Path() string
@@ -56,7 +58,7 @@ type (
 		// 	SetParamValues(values ...string)
 
 		// QueryParam returns the query param for the provided name.
-		// 	QueryParam(name string) string
+		QueryParam(name string) string
 
 		// QueryParams returns the query parameters as `url.Values`.
 		QueryParams() url.Values
@@ -65,10 +67,10 @@ type (
 		// 	QueryString() string
 
 		// FormValue returns the form field value for the provided name.
-		// 	FormValue(name string) string
+		FormValue(name string) string
 
 		// FormParams returns the form parameters as `url.Values`.
-		// 	FormParams() (url.Values, error)
+		FormParams() (url.Values, error)
 
 		// FormFile returns the multipart form file for the provided name.
 		// 	FormFile(name string) (*multipart.FileHeader, error)
@@ -77,13 +79,13 @@ type (
 		// 	MultipartForm() (*multipart.Form, error)
 
 		// Cookie returns the named cookie provided in the request.
-		// 	Cookie(name string) (*http.Cookie, error)
+		Cookie(name string) (*http.Cookie, error)
 
 		// SetCookie adds a `Set-Cookie` header in HTTP response.
-		// 	SetCookie(cookie *http.Cookie)
+		SetCookie(cookie *http.Cookie)
 
 		// Cookies returns the HTTP cookies sent with the request.
-		// 	Cookies() []*http.Cookie
+		Cookies() []*http.Cookie
 
 		// Get retrieves data from the context.
 		Get(key string) interface{}
@@ -139,7 +141,7 @@ type (
 		// 	XMLBlob(code int, b []byte) error
 
 		// Blob sends a blob response with status code and content type.
-		// 	Blob(code int, contentType string, b []byte) error
+		Blob(code int, contentType string, b []byte) error
 
 		// Stream sends a streaming response with status code and content type.
 		// 	Stream(code int, contentType string, r io.Reader) error
@@ -158,10 +160,10 @@ type (
 		NoContent(code int) error
 
 		// Redirect redirects the request to a provided URL with status code.
-		// 	Redirect(code int, url string) error
+		Redirect(code int, url string) error
 
 		// Error invokes the registered HTTP error handler. Generally used by middleware.
-		// 	Error(err error)
+		Error(err error)
 
 		// Handler returns the matched handler by router.
 		Handler() HandlerFunc
@@ -170,13 +172,13 @@ type (
 		// 	SetHandler(h HandlerFunc)
 
 		// Logger returns the `Logger` instance.
-		// 	Logger() Logger
+		Logger() Logger
 
 		// Set the logger
 		// 	SetLogger(l Logger)
 
 		// Echo returns the `Echo` instance.
-		// 	Echo() *Echo
+		Echo() *Echo
 
 		// Reset resets the context after request completes. It must be called along
 		// with `Echo#AcquireContext()` and `Echo#ReleaseContext()`.
@@ -216,14 +218,65 @@ func (c *context) Request() *http.Request {
 	return c.request
 }
 
+func (c *context) SetRequest(r *http.Request) {
+	c.request = r
+}
+
 func (c *context) Response() *Response {
 	return c.response
 }
 
+func (c *context) IsTLS() bool {
+	return c.request.TLS != nil
+}
+
+func (c *context) Scheme() string {
+	// Can't use `r.Request.URL.Scheme`
+	// See: https://groups.google.com/forum/#!topic/golang-nuts/pMUkBlQBDF0
+	if c.IsTLS() {
+		return "https"
+	}
+	if scheme := c.request.Header.Get(HeaderXForwardedProto); scheme != "" {
+		return scheme
+	}
+	if scheme := c.request.Header.Get(HeaderXForwardedProtocol); scheme != "" {
+		return scheme
+	}
+	if ssl := c.request.Header.Get(HeaderXForwardedSsl); ssl == "on" {
+		return "https"
+	}
+	if scheme := c.request.Header.Get(HeaderXUrlScheme); scheme != "" {
+		return scheme
+	}
+	return "http"
+}
+
+func (c *context) RealIP() string {
+	// if c.echo != nil && c.echo.IPExtractor != nil {
+	// 	return c.echo.IPExtractor(c.request)
+	// }
+	if ip := c.request.Header.Get(HeaderXForwardedFor); ip != "" {
+		i := strings.IndexAny(ip, ",")
+		if i > 0 {
+			return strings.TrimSpace(ip[:i])
+		}
+		return ip
+	}
+	if ip := c.request.Header.Get(HeaderXRealIP); ip != "" {
+		return ip
+	}
+	ra, _, _ := net.SplitHostPort(c.request.RemoteAddr)
+	return ra
+}
+
 func (c *context) Path() string {
 	return c.path
 }
 
+func (c *context) QueryParam(name string) string {
+	return c.QueryParams().Get(name)
+}
+
 func (c *context) QueryParams() url.Values {
 	if c.query == nil {
 		c.query = c.request.URL.Query()
@@ -231,6 +284,35 @@ func (c *context) QueryParams() url.Values {
 	return c.query
 }
 
+func (c *context) FormValue(name string) string {
+	return c.request.FormValue(name)
+}
+
+func (c *context) FormParams() (url.Values, error) {
+	if strings.HasPrefix(c.request.Header.Get(HeaderContentType), MIMEMultipartForm) {
+		if err := c.request.ParseMultipartForm(defaultMemory); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := c.request.ParseForm(); err != nil {
+			return nil, err
+		}
+	}
+	return c.request.Form, nil
+}
+
+func (c *context) Cookie(name string) (*http.Cookie, error) {
+	return c.request.Cookie(name)
+}
+
+func (c *context) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(c.Response(), cookie)
+}
+
+func (c *context) Cookies() []*http.Cookie {
+	return c.request.Cookies()
+}
+
 func (c *context) Get(key string) interface{} {
 	// c.lock.RLock()
 	// defer c.lock.RUnlock()
@@ -281,17 +363,38 @@ func (c *context) NoContent(code int) error {
 	return nil
 }
 
+func (c *context) Redirect(code int, url string) error {
+	if code < 300 || code > 308 {
+		return ErrInvalidRedirectCode
+	}
+	c.response.Header().Set(HeaderLocation, url)
+	c.response.WriteHeader(code)
+	return nil
+}
+
+func (c *context) Error(err error) {
+	c.echo.HTTPErrorHandler(err, c)
+}
+
 func (c *context) Handler() HandlerFunc {
 	return c.handler
 }
 
+func (c *context) Logger() Logger {
+	return c.echo.Logger
+}
+
+func (c *context) Echo() *Echo {
+	return c.echo
+}
+
 func (c *context) Reset(r *http.Request, w http.ResponseWriter) {
 	c.request = r
 	c.response.reset(w)
-	// c.query = nil
+	c.query = nil
 	c.handler = NotFoundHandler
-	// c.store = nil
-	// c.path = ""
+	c.store = nil
+	c.path = ""
 	// c.pnames = nil
 	// c.logger = nil
 
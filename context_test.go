@@ -0,0 +1,129 @@
+package echo
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_Scheme(t *testing.T) {
+	tests := []struct {
+		c Context
+		s string
+	}{
+		{
+			&context{
+				request: &http.Request{
+					TLS: &tls.ConnectionState{},
+				},
+			},
+			"https",
+		},
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{HeaderXForwardedProto: []string{"https"}},
+				},
+			},
+			"https",
+		},
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{HeaderXForwardedProtocol: []string{"http"}},
+				},
+			},
+			"http",
+		},
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{HeaderXForwardedSsl: []string{"on"}},
+				},
+			},
+			"https",
+		},
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{HeaderXUrlScheme: []string{"https"}},
+				},
+			},
+			"https",
+		},
+		{
+			&context{
+				request: &http.Request{},
+			},
+			"http",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.s, tt.c.Scheme())
+	}
+}
+
+func TestContext_RealIP(t *testing.T) {
+	tests := []struct {
+		c Context
+		s string
+	}{
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{HeaderXForwardedFor: []string{"127.0.0.1, 127.0.1.1, "}},
+				},
+			},
+			"127.0.0.1",
+		},
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{"X-Real-Ip": []string{"192.168.0.1"}},
+				},
+			},
+			"192.168.0.1",
+		},
+		{
+			&context{
+				request: &http.Request{
+					RemoteAddr: "89.89.89.89:1654",
+				},
+			},
+			"89.89.89.89",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.s, tt.c.RealIP())
+	}
+}
+
+func TestContext_Reset(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/?q=1", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.Set("key", "val")
+	assert.Equal(t, "1", c.QueryParams().Get("q"))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Reset(req, httptest.NewRecorder())
+	assert.Nil(t, c.Get("key"))
+	assert.Empty(t, c.QueryParams())
+	assert.Equal(t, "", c.Path())
+}
+
+func TestContext_Redirect(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	assert.Equal(t, ErrInvalidRedirectCode, c.Redirect(http.StatusOK, "/login"))
+	assert.NoError(t, c.Redirect(http.StatusMovedPermanently, "/login"))
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/login", rec.Header().Get(HeaderLocation))
+}
@@ -2,43 +2,76 @@ package echo
 
 import (
 	stdContext "context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type (
 	Echo struct {
 		// common
 
-		// startupMutex sync.RWMutex
+		startupMutex sync.RWMutex
 		// StdLogger        *stdLog.Logger
-		// colorer          *color.Color
-		// premiddleware    []MiddlewareFunc
-		// middleware       []MiddlewareFunc
+		colorer       *colorer
+		premiddleware []MiddlewareFunc
+		middleware    []MiddlewareFunc
 		// maxParam *int
-		router  *Router
+		router *Router
 		// routers map[string]*Router
 		// notFoundHandler  HandlerFunc
-		pool   sync.Pool
-		Server *http.Server
-		// TLSServer   *http.Server
-		Listener net.Listener
-		// TLSListener net.Listener
-		// AutoTLSManager   autocert.Manager
-		DisableHTTP2 bool
-		Debug        bool
-		// HideBanner   bool
-		// HidePort bool
+		pool             sync.Pool
+		Server           *http.Server
+		TLSServer        *http.Server
+		Listener         net.Listener
+		TLSListener      net.Listener
+		rawTLSListener   net.Listener // TLSListener before the TLS layer
+		restart          *restartState
+		AutoTLSManager   autocert.Manager
+		acmeHandler      atomic.Value
+		DisableHTTP2     bool
+		Debug            bool
+		HideBanner       bool
+		HidePort         bool
 		HTTPErrorHandler HTTPErrorHandler
 		// Binder           Binder
 		// Validator        Validator
 		// Renderer         Renderer
 		Logger Logger
 		// IPExtractor      IPExtractor
-		ListenerNetwork string
+		ListenerNetwork   string
+		ListenerKeepAlive time.Duration
+		UnixSocket        UnixSocketConfig
+	}
+
+	// UnixSocketConfig defines the socket file created when `Echo#ListenerNetwork`
+	// is "unix".
+	UnixSocketConfig struct {
+		// Mode sets the permissions of the socket file, e.g. 0660.
+		// Optional. Default value 0, keeping the permissions set by the umask.
+		Mode os.FileMode
+
+		// Owner and Group set the ownership of the socket file, either as names
+		// or numeric ids.
+		// Optional. Default value "", keeping the owner and group of the process.
+		Owner string
+		Group string
 	}
 
 	Route struct {
@@ -60,6 +93,23 @@ type (
 	Map map[string]interface{}
 )
 
+const (
+	// Version of Echo
+	Version = "0.1.0"
+	website = "https://github.com/Ken2mer/echo-mini"
+	// http://patorjk.com/software/taag/#p=display&f=Small%20Slant&t=Echo
+	banner = `
+   ____    __
+  / __/___/ /  ___
+ / _// __/ _ \/ _ \
+/___/\__/_//_/\___/ %s
+High performance, minimalist Go web framework
+%s
+____________________________________O/_______
+                                    O\
+`
+)
+
 // MIME types
 const (
 	MIMEApplicationJSON                  = "application/json"
@@ -89,6 +139,16 @@ const (
 	REPORT = "REPORT"
 )
 
+const (
+	// acmeChallengePath is the path prefix of ACME HTTP-01 challenge requests.
+	acmeChallengePath = "/.well-known/acme-challenge/"
+
+	// ContextKeyHeaderAllow is set by Router for getting value for `Allow` header in later stages of handler call chain.
+	// Allow header is mandatory for status 405 (method not found) and useful for OPTIONS method requests.
+	// It is added to context only when Router does not find matching method handler for request.
+	ContextKeyHeaderAllow = "echo_header_allow"
+)
+
 // Headers
 const (
 	HeaderAccept              = "Accept"
@@ -108,6 +168,7 @@ const (
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
 	HeaderXForwardedFor       = "X-Forwarded-For"
+	HeaderXForwardedHost      = "X-Forwarded-Host"
 	HeaderXForwardedProto     = "X-Forwarded-Proto"
 	HeaderXForwardedProtocol  = "X-Forwarded-Protocol"
 	HeaderXForwardedSsl       = "X-Forwarded-Ssl"
@@ -118,6 +179,12 @@ const (
 	HeaderXRequestedWith      = "X-Requested-With"
 	HeaderServer              = "Server"
 	HeaderOrigin              = "Origin"
+	HeaderRetryAfter          = "Retry-After"
+
+	// Rate limiting
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
 
 	// Access control
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
@@ -185,24 +252,37 @@ var (
 	}
 
 	MethodNotAllowedHandler = func(c Context) error {
+		// See RFC 7231 section 7.4.1: An origin server MUST generate an Allow field in a 405 (Method Not Allowed)
+		// response and MAY do so in any other response. For disabled resources an empty Allow header may be returned
+		routerAllowMethods, ok := c.Get(ContextKeyHeaderAllow).(string)
+		if ok && routerAllowMethods != "" {
+			c.Response().Header().Set(HeaderAllow, routerAllowMethods)
+		}
 		return ErrMethodNotAllowed
 	}
 )
 
+func optionsMethodHandler(c Context) error {
+	if allow, ok := c.Get(ContextKeyHeaderAllow).(string); ok && allow != "" {
+		c.Response().Header().Set(HeaderAllow, allow)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
 func New() (e *Echo) {
 	e = &Echo{
-		Server: new(http.Server),
-		// TLSServer: new(http.Server),
-		// AutoTLSManager: autocert.Manager{
-		// 	Prompt: autocert.AcceptTOS,
-		// },
-		// Logger:          log.New("echo"),
-		// colorer:         color.New(),
+		Server:    new(http.Server),
+		TLSServer: new(http.Server),
+		AutoTLSManager: autocert.Manager{
+			Prompt: autocert.AcceptTOS,
+		},
+		Logger:  newLogger("echo"),
+		colorer: newColorer(),
 		// maxParam:        new(int),
 		ListenerNetwork: "tcp",
 	}
 	// e.Server.Handler = e
-	// e.TLSServer.Handler = e
+	e.TLSServer.Handler = e
 	e.HTTPErrorHandler = e.DefaultHTTPErrorHandler
 	// e.Binder = &DefaultBinder{}
 	// e.Logger.SetLevel(log.ERROR)
@@ -222,7 +302,32 @@ func (e *Echo) NewContext(r *http.Request, w http.ResponseWriter) Context {
 		// store:    make(Map),
 		echo: e,
 		// pvalues:  make([]string, *e.maxParam),
-		// handler: NotFoundHandler,
+		handler: NotFoundHandler,
+	}
+}
+
+// ForkContext returns a pooled `Context` serving r and w with the path, handler
+// and store of c, so the copy can outlive c, e.g. in a handler goroutine.
+// It must be returned with `ReleaseContext()` once it is not used anymore.
+func (e *Echo) ForkContext(c Context, r *http.Request, w http.ResponseWriter) Context {
+	fc := e.pool.Get().(*context)
+	fc.Reset(r, w)
+	fc.path = c.Path()
+	fc.handler = c.Handler()
+	if src, ok := c.(*context); ok && len(src.store) > 0 {
+		fc.store = make(Map, len(src.store))
+		for k, v := range src.store {
+			fc.store[k] = v
+		}
+	}
+	return fc
+}
+
+// ReleaseContext returns a `Context` from `ForkContext()` to the pool. Other
+// implementations of `Context` are ignored.
+func (e *Echo) ReleaseContext(c Context) {
+	if fc, ok := c.(*context); ok {
+		e.pool.Put(fc)
 	}
 }
 
@@ -235,6 +340,10 @@ func (e *Echo) Router() *Router {
 // }
 
 func (e *Echo) DefaultHTTPErrorHandler(err error, c Context) {
+	if c.Response().Committed {
+		return
+	}
+
 	he, ok := err.(*HTTPError)
 	if !ok {
 		he = &HTTPError{
@@ -249,10 +358,52 @@ func (e *Echo) DefaultHTTPErrorHandler(err error, c Context) {
 	}
 }
 
+// Pre adds middleware to the chain which is run before router.
+func (e *Echo) Pre(middleware ...MiddlewareFunc) {
+	e.premiddleware = append(e.premiddleware, middleware...)
+}
+
+// Use adds middleware to the chain which is run after router.
+func (e *Echo) Use(middleware ...MiddlewareFunc) {
+	e.middleware = append(e.middleware, middleware...)
+}
+
+func (e *Echo) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodConnect, path, h, m...)
+}
+
+func (e *Echo) DELETE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodDelete, path, h, m...)
+}
+
 func (e *Echo) GET(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
 	return e.Add(http.MethodGet, path, h, m...)
 }
 
+func (e *Echo) HEAD(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodHead, path, h, m...)
+}
+
+func (e *Echo) OPTIONS(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodOptions, path, h, m...)
+}
+
+func (e *Echo) PATCH(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodPatch, path, h, m...)
+}
+
+func (e *Echo) POST(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodPost, path, h, m...)
+}
+
+func (e *Echo) PUT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodPut, path, h, m...)
+}
+
+func (e *Echo) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.Add(http.MethodTrace, path, h, m...)
+}
+
 func (e *Echo) add(host, method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
 	// name := handlerName(handler)
 	router := e.findRouter(host)
@@ -282,24 +433,31 @@ func (e *Echo) Routes() []*Route {
 }
 
 func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// ACME HTTP-01 challenges are answered ahead of the routes, see `StartAutoTLS()`.
+	if h, ok := e.acmeHandler.Load().(http.Handler); ok && strings.HasPrefix(r.URL.Path, acmeChallengePath) {
+		h.ServeHTTP(w, r)
+		return
+	}
+
 	// Acquire context
 	c := e.pool.Get().(*context)
 	c.Reset(r, w)
 	h := NotFoundHandler
 
-	// if e.premiddleware == nil {
-	// 	e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
-	// 	h = c.Handler()
-	// 	h = applyMiddleware(h, e.middleware...)
-	// } else {
-	h = func(c Context) error {
+	if e.premiddleware == nil {
 		e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
-		h := c.Handler()
-		// h = applyMiddleware(h, e.middleware...)
-		return h(c)
+		h = c.Handler()
+		h = applyMiddleware(h, e.middleware...)
+	} else {
+		h = func(c Context) error {
+			r := c.Request()
+			e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
+			h := c.Handler()
+			h = applyMiddleware(h, e.middleware...)
+			return h(c)
+		}
+		h = applyMiddleware(h, e.premiddleware...)
 	}
-	// h = applyMiddleware(h, e.premiddleware...)
-	// }
 
 	// Execute chain
 	if err := h(c); err != nil {
@@ -311,79 +469,207 @@ func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (e *Echo) Start(address string) error {
-	// e.startupMutex.Lock()
+	e.startupMutex.Lock()
 	e.Server.Addr = address
 	if err := e.configureServer(e.Server); err != nil {
-		// e.startupMutex.Unlock()
+		e.startupMutex.Unlock()
 		return err
 	}
-	// e.startupMutex.Unlock()
+	e.startupMutex.Unlock()
 	return e.Server.Serve(e.Listener)
 }
 
+// StartTLS starts an HTTPS server.
+// If `certFile` or `keyFile` is `string` the values are treated as file paths.
+// If `certFile` or `keyFile` is `[]byte` the values are treated as the certificate or key as-is.
+func (e *Echo) StartTLS(address string, certFile, keyFile interface{}) (err error) {
+	e.startupMutex.Lock()
+	var cert []byte
+	if cert, err = filepathOrContent(certFile); err != nil {
+		e.startupMutex.Unlock()
+		return
+	}
+
+	var key []byte
+	if key, err = filepathOrContent(keyFile); err != nil {
+		e.startupMutex.Unlock()
+		return
+	}
+
+	s := e.TLSServer
+	s.TLSConfig = new(tls.Config)
+	s.TLSConfig.Certificates = make([]tls.Certificate, 1)
+	if s.TLSConfig.Certificates[0], err = tls.X509KeyPair(cert, key); err != nil {
+		e.startupMutex.Unlock()
+		return
+	}
+
+	e.configureTLS(address)
+	if err := e.configureServer(s); err != nil {
+		e.startupMutex.Unlock()
+		return err
+	}
+	e.startupMutex.Unlock()
+	return s.Serve(e.TLSListener)
+}
+
+// StartH2CServer starts a custom http/2 server with h2c (HTTP/2 Cleartext).
+// Clients may either connect with prior knowledge of HTTP/2 or upgrade an
+// HTTP/1.1 connection with `Upgrade: h2c`. A nil h2s uses the defaults.
+func (e *Echo) StartH2CServer(address string, h2s *http2.Server) error {
+	e.startupMutex.Lock()
+	if h2s == nil {
+		h2s = new(http2.Server)
+	}
+	s := e.Server
+	s.Addr = address
+	if err := e.configureServer(s); err != nil {
+		e.startupMutex.Unlock()
+		return err
+	}
+	s.Handler = h2c.NewHandler(e, h2s)
+	e.startupMutex.Unlock()
+	return s.Serve(e.Listener)
+}
+
+// StartAutoTLS starts an HTTPS server using certificates automatically
+// installed from an ACME CA, https://letsencrypt.org unless
+// `AutoTLSManager.Client` is set.
+//
+// Domains are validated with the TLS-ALPN-01 challenge, or the HTTP-01 challenge
+// when port 80 is served by `Start()` too. Use `AutoTLSManager.HostPolicy`, e.g.
+// `autocert.HostWhitelist()`, to restrict the hosts certificates are requested
+// for. Certificates are kept in `AutoTLSManager.Cache`, which defaults to a
+// directory cache in the user's cache directory.
+func (e *Echo) StartAutoTLS(address string) error {
+	e.startupMutex.Lock()
+	if e.AutoTLSManager.Cache == nil {
+		e.AutoTLSManager.Cache = autocert.DirCache(defaultAutoTLSCacheDir())
+	}
+	e.acmeHandler.Store(e.AutoTLSManager.HTTPHandler(nil))
+
+	s := e.TLSServer
+	s.TLSConfig = new(tls.Config)
+	s.TLSConfig.GetCertificate = e.AutoTLSManager.GetCertificate
+	s.TLSConfig.NextProtos = append(s.TLSConfig.NextProtos, acme.ALPNProto)
+
+	e.configureTLS(address)
+	// Keep HTTP/1.1 clients working when HTTP/2 is disabled.
+	s.TLSConfig.NextProtos = append(s.TLSConfig.NextProtos, "http/1.1")
+	if err := e.configureServer(s); err != nil {
+		e.startupMutex.Unlock()
+		return err
+	}
+	e.startupMutex.Unlock()
+	return s.Serve(e.TLSListener)
+}
+
+func defaultAutoTLSCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "echo", "autocert")
+}
+
+func filepathOrContent(fileOrContent interface{}) (content []byte, err error) {
+	switch v := fileOrContent.(type) {
+	case string:
+		return ioutil.ReadFile(v)
+	case []byte:
+		return v, nil
+	default:
+		return nil, ErrInvalidCertOrKeyType
+	}
+}
+
+func (e *Echo) configureTLS(address string) {
+	s := e.TLSServer
+	s.Addr = address
+	if !e.DisableHTTP2 {
+		s.TLSConfig.NextProtos = append(s.TLSConfig.NextProtos, "h2")
+	}
+}
+
 func (e *Echo) configureServer(s *http.Server) (err error) {
 	// Setup
-	// e.colorer.SetOutput(e.Logger.Output())
+	e.colorer.SetOutput(e.Logger.Output())
 	// s.ErrorLog = e.StdLogger
 	s.Handler = e
 	if e.Debug {
 		// e.Logger.SetLevel(log.DEBUG)
 	}
 
-	// if !e.HideBanner {
-	// 	e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
-	// }
+	if !e.HideBanner {
+		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
+	}
 
 	if s.TLSConfig == nil {
 		if e.Listener == nil {
-			e.Listener, err = newListener(s.Addr, e.ListenerNetwork)
+			e.Listener, err = e.newListener(s.Addr)
 			if err != nil {
 				return err
 			}
 		}
-		// if !e.HidePort {
-		// 	e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(e.Listener.Addr()))
-		// }
+		if !e.HidePort {
+			e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(e.Listener.Addr()))
+		}
+		e.inheritedListenerStarted(listenerNameHTTP)
 		return nil
 	}
-	// if e.TLSListener == nil {
-	// 	l, err := newListener(s.Addr, e.ListenerNetwork)
-	// 	if err != nil {
-	// 		return err
-	// 	}
-	// 	e.TLSListener = tls.NewListener(l, s.TLSConfig)
-	// }
-	// if !e.HidePort {
-	// 	e.colorer.Printf("⇨ https server started on %s\n", e.colorer.Green(e.TLSListener.Addr()))
-	// }
+	if e.TLSListener == nil {
+		if e.rawTLSListener == nil {
+			if e.rawTLSListener, err = e.newListener(s.Addr); err != nil {
+				return err
+			}
+		}
+		e.TLSListener = tls.NewListener(e.rawTLSListener, s.TLSConfig)
+	}
+	if !e.HidePort {
+		e.colorer.Printf("⇨ https server started on %s\n", e.colorer.Green(e.TLSListener.Addr()))
+	}
+	e.inheritedListenerStarted(listenerNameHTTPS)
 	return nil
 }
 
 func (e *Echo) ListenerAddr() net.Addr {
-	// e.startupMutex.RLock()
-	// defer e.startupMutex.RUnlock()
+	e.startupMutex.RLock()
+	defer e.startupMutex.RUnlock()
 	if e.Listener == nil {
 		return nil
 	}
 	return e.Listener.Addr()
 }
 
+// TLSListenerAddr returns net.Addr for TLSListener.
+func (e *Echo) TLSListenerAddr() net.Addr {
+	e.startupMutex.RLock()
+	defer e.startupMutex.RUnlock()
+	if e.TLSListener == nil {
+		return nil
+	}
+	return e.TLSListener.Addr()
+}
+
 func (e *Echo) Close() error {
-	// e.startupMutex.Lock()
-	// defer e.startupMutex.Unlock()
-	// if err := e.TLSServer.Close(); err != nil {
-	// 	return err
-	// }
+	e.startupMutex.Lock()
+	defer e.startupMutex.Unlock()
+	if err := e.TLSServer.Close(); err != nil {
+		return err
+	}
 	return e.Server.Close()
 }
 
 func (e *Echo) Shutdown(ctx stdContext.Context) error {
-	// e.startupMutex.Lock()
-	// defer e.startupMutex.Unlock()
-	// if err := e.TLSServer.Shutdown(ctx); err != nil {
-	// 	return err
-	// }
-	return e.Server.Shutdown(ctx)
+	// The lock is not held while draining, so `Close()` can still stop the
+	// servers when the shutdown hangs.
+	e.startupMutex.Lock()
+	tlsServer, server := e.TLSServer, e.Server
+	e.startupMutex.Unlock()
+	if err := tlsServer.Shutdown(ctx); err != nil {
+		return err
+	}
+	return server.Shutdown(ctx)
 }
 
 func NewHTTPError(code int, message ...interface{}) *HTTPError {
@@ -401,6 +687,17 @@ func (he *HTTPError) Error() string {
 	return fmt.Sprintf("code=%d, message=%v, internal=%v", he.Code, he.Message, he.Internal)
 }
 
+// SetInternal sets error to HTTPError.Internal
+func (he *HTTPError) SetInternal(err error) *HTTPError {
+	he.Internal = err
+	return he
+}
+
+// Unwrap satisfies the Go 1.13 error wrapper interface.
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
+
 func GetPath(r *http.Request) string {
 	path := r.URL.RawPath
 	if path == "" {
@@ -418,24 +715,195 @@ func (e *Echo) findRouter(host string) *Router {
 	return e.router
 }
 
+// tcpKeepAliveListener sets TCP keep-alive timeouts on accepted
+// connections. It's used by Start, StartTLS and StartH2CServer so dead TCP
+// connections (e.g. closing laptop mid-download) eventually go away.
 type tcpKeepAliveListener struct {
 	*net.TCPListener
+	// keepAlive is the keep-alive period, zero uses defaultKeepAlive and a
+	// negative value disables keep-alives.
+	keepAlive time.Duration
+}
+
+const defaultKeepAlive = 3 * time.Minute
+
+func (ln tcpKeepAliveListener) Accept() (net.Conn, error) {
+	c, err := ln.AcceptTCP()
+	if err != nil {
+		return nil, err
+	}
+	if ln.keepAlive < 0 {
+		c.SetKeepAlive(false)
+		return c, nil
+	}
+	period := ln.keepAlive
+	if period == 0 {
+		period = defaultKeepAlive
+	}
+	// Errors are ignored, keep-alives are best effort.
+	c.SetKeepAlive(true)
+	c.SetKeepAlivePeriod(period)
+	return c, nil
 }
 
-func newListener(address, network string) (*tcpKeepAliveListener, error) {
-	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+func (e *Echo) newListener(address string) (net.Listener, error) {
+	switch e.ListenerNetwork {
+	case "tcp", "tcp4", "tcp6":
+		l, err := net.Listen(e.ListenerNetwork, address)
+		if err != nil {
+			return nil, err
+		}
+		return &tcpKeepAliveListener{TCPListener: l.(*net.TCPListener), keepAlive: e.ListenerKeepAlive}, nil
+	case "unix":
+		return newUnixListener(address, e.UnixSocket)
+	default:
 		return nil, ErrInvalidListenerNetwork
 	}
-	l, err := net.Listen(network, address)
+}
+
+func newUnixListener(path string, config UnixSocketConfig) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
 	if err != nil {
 		return nil, err
 	}
-	return &tcpKeepAliveListener{l.(*net.TCPListener)}, nil
+	if err := configureUnixSocket(path, config); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes the socket file left at path by a server which did
+// not shut down cleanly. Sockets which still accept connections are kept.
+func removeStaleSocket(path string) error {
+	if path == "" || path[0] == '@' { // abstract sockets have no file
+		return nil
+	}
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return nil // Listen reports what is wrong with the path
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return nil // in use, Listen fails with "address already in use"
+	}
+	return os.Remove(path)
+}
+
+func configureUnixSocket(path string, config UnixSocketConfig) error {
+	if config.Mode != 0 {
+		if err := os.Chmod(path, config.Mode); err != nil {
+			return err
+		}
+	}
+	if config.Owner == "" && config.Group == "" {
+		return nil
+	}
+	uid, gid := -1, -1 // unchanged
+	var err error
+	if config.Owner != "" {
+		if uid, err = lookupID(config.Owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		}); err != nil {
+			return err
+		}
+	}
+	if config.Group != "" {
+		if gid, err = lookupID(config.Group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		}); err != nil {
+			return err
+		}
+	}
+	return os.Chown(path, uid, gid)
+}
+
+// lookupID returns the numeric id given by nameOrID, using lookup for names.
+func lookupID(nameOrID string, lookup func(name string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+	id, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id)
+}
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// SystemdListeners returns the listeners passed to the process by systemd
+// socket activation, in the order of the sockets in the socket unit, or none if
+// the process was not socket activated. The LISTEN_PID, LISTEN_FDS and
+// LISTEN_FDNAMES environment variables are unset, so they are not passed on to
+// child processes.
+//
+// TCP listeners use `Echo#ListenerKeepAlive`. Set one of them as
+// `Echo#Listener` before starting the server.
+func (e *Echo) SystemdListeners() ([]net.Listener, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	if pid != strconv.Itoa(os.Getpid()) || fds == "" {
+		return nil, nil // meant for another process
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("echo: invalid LISTEN_FDS=%q", fds)
+	}
+	var fdNames []string
+	if names != "" {
+		fdNames = strings.Split(names, ":")
+	}
+	return e.inheritedListeners(n, fdNames)
+}
+
+// inheritedListeners returns listeners for the n file descriptors inherited
+// from the parent process, starting at listenFDsStart. TCP listeners use
+// `Echo#ListenerKeepAlive`.
+func (e *Echo) inheritedListeners(n int, names []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		fd := listenFDsStart + i
+		name := fmt.Sprintf("fd%d", fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		f.Close() // FileListener works on a duplicate
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("echo: inherited listener %s: %w", name, err)
+		}
+		if tl, ok := l.(*net.TCPListener); ok {
+			l = &tcpKeepAliveListener{TCPListener: tl, keepAlive: e.ListenerKeepAlive}
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
 }
 
 func applyMiddleware(h HandlerFunc, middleware ...MiddlewareFunc) HandlerFunc {
-	// for i := len(middleware) - 1; i >= 0; i-- {
-	// 	h = middleware[i](h)
-	// }
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
 	return h
 }
@@ -1,17 +1,38 @@
 package echo
 
 import (
+	"bufio"
+	"bytes"
 	stdContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 func TestEcho(t *testing.T) {
@@ -42,6 +63,59 @@ func TestEchoHandler(t *testing.T) {
 	assert.Equal(t, "OK", b)
 }
 
+func TestEchoMiddleware(t *testing.T) {
+	e := New()
+	buf := new(strings.Builder)
+
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			// before router, no route is matched yet
+			assert.Empty(t, c.Path())
+			buf.WriteString("-")
+			return next(c)
+		}
+	})
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			buf.WriteString("a")
+			return next(c)
+		}
+	})
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			buf.WriteString("b")
+			return next(c)
+		}
+	})
+
+	// Route
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	c, b := request(http.MethodGet, "/", e)
+	assert.Equal(t, "-ab", buf.String())
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "OK", b)
+}
+
+func TestEchoPreRewrite(t *testing.T) {
+	e := New()
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Request().URL.Path = "/new"
+			return next(c)
+		}
+	})
+	e.GET("/new", func(c Context) error {
+		return c.String(http.StatusOK, "new")
+	})
+
+	c, b := request(http.MethodGet, "/old", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "new", b)
+}
+
 func TestEchoGet(t *testing.T) {
 	e := New()
 	testMethod(t, http.MethodGet, "/", e)
@@ -103,6 +177,45 @@ func TestEchoContext(t *testing.T) {
 	e.pool.Put(c)
 }
 
+func TestEchoForkContext(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.Set("user", "jon")
+	c.(*context).path = "/users"
+
+	rec := httptest.NewRecorder()
+	fc := e.ForkContext(c, req, rec)
+	assert.Equal(t, "/users", fc.Path())
+	assert.Equal(t, "jon", fc.Get("user"))
+
+	// The fork owns its store and response.
+	fc.Set("user", "joe")
+	assert.Equal(t, "jon", c.Get("user"))
+	assert.NoError(t, fc.String(http.StatusOK, "fork"))
+	assert.Equal(t, "fork", rec.Body.String())
+
+	e.ReleaseContext(fc)
+}
+
+type foreignContext struct {
+	Context
+}
+
+func TestEchoReleaseContext_foreign(t *testing.T) {
+	e := New()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+	e.ReleaseContext(foreignContext{})
+
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, "OK", rec.Body.String())
+}
+
 func waitForServerStart(e *Echo, errChan <-chan error, isTLS bool) error {
 	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 200*time.Millisecond)
 	defer cancel()
@@ -117,7 +230,7 @@ func waitForServerStart(e *Echo, errChan <-chan error, isTLS bool) error {
 		case <-ticker.C:
 			var addr net.Addr
 			if isTLS {
-				// addr = e.TLSListenerAddr()
+				addr = e.TLSListenerAddr()
 			} else {
 				addr = e.ListenerAddr()
 			}
@@ -133,8 +246,17 @@ func waitForServerStart(e *Echo, errChan <-chan error, isTLS bool) error {
 	}
 }
 
-func TestEchoStart(t *testing.T) {
+// newTestEcho returns an Echo which does not print the startup banner and
+// address, keeping the test output readable.
+func newTestEcho() *Echo {
 	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	return e
+}
+
+func TestEchoStart(t *testing.T) {
+	e := newTestEcho()
 	errChan := make(chan error)
 
 	go func() {
@@ -150,6 +272,411 @@ func TestEchoStart(t *testing.T) {
 	assert.NoError(t, e.Close())
 }
 
+func TestEchoStartBanner(t *testing.T) {
+	cert, key := testCertificate(t)
+	testCases := []struct {
+		name         string
+		hideBanner   bool
+		hidePort     bool
+		tls          bool
+		expectBanner bool
+		expectPort   string
+	}{
+		{name: "banner and port", expectBanner: true, expectPort: "⇨ http server started on 127.0.0.1:"},
+		{name: "tls", tls: true, expectBanner: true, expectPort: "⇨ https server started on 127.0.0.1:"},
+		{name: "hide banner", hideBanner: true, expectPort: "⇨ http server started on 127.0.0.1:"},
+		{name: "hide port", hidePort: true, expectBanner: true},
+		{name: "hide both", hideBanner: true, hidePort: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			e := New()
+			e.Logger.SetOutput(buf)
+			e.HideBanner = tc.hideBanner
+			e.HidePort = tc.hidePort
+
+			ctx, cancel := stdContext.WithCancel(stdContext.Background())
+			sc := &StartConfig{Address: "127.0.0.1:0"}
+			_, errCh := startServer(t, sc, func(sc StartConfig) error {
+				if tc.tls {
+					return sc.StartTLS(ctx, e, cert, key)
+				}
+				return sc.Start(ctx, e)
+			})
+			out := buf.String()
+			cancel()
+			assert.NoError(t, <-errCh)
+
+			// colors are disabled when not writing to a terminal
+			assert.NotContains(t, out, "\x1b[")
+			assert.Equal(t, tc.expectBanner, strings.Contains(out, "v"+Version))
+			assert.Equal(t, tc.expectBanner, strings.Contains(out, website))
+			if tc.expectPort != "" {
+				assert.Contains(t, out, tc.expectPort)
+			} else {
+				assert.NotContains(t, out, "server started on")
+			}
+			if !tc.expectBanner && tc.expectPort == "" {
+				assert.Empty(t, out)
+			}
+		})
+	}
+}
+
+// testCertificate returns a PEM encoded self-signed certificate and key for
+// localhost.
+func testCertificate(t *testing.T) (cert, key []byte) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Echo"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return cert, key
+}
+
+func tlsClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+}
+
+func TestEchoStartTLS(t *testing.T) {
+	cert, key := testCertificate(t)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.NoError(t, ioutil.WriteFile(certFile, cert, 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, key, 0600))
+
+	testCases := []struct {
+		name     string
+		certFile interface{}
+		keyFile  interface{}
+	}{
+		{name: "content", certFile: cert, keyFile: key},
+		{name: "file", certFile: certFile, keyFile: keyFile},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEcho()
+			e.GET("/", func(c Context) error {
+				return c.String(http.StatusOK, c.Request().Proto)
+			})
+			errCh := make(chan error)
+			go func() {
+				errCh <- e.StartTLS("127.0.0.1:0", tc.certFile, tc.keyFile)
+			}()
+			if !assert.NoError(t, waitForServerStart(e, errCh, true)) {
+				return
+			}
+			defer e.Close()
+
+			res, err := tlsClient().Get("https://" + e.TLSListenerAddr().String())
+			if assert.NoError(t, err) {
+				body, _ := ioutil.ReadAll(res.Body)
+				res.Body.Close()
+				assert.Equal(t, "HTTP/2.0", string(body))
+			}
+		})
+	}
+}
+
+func TestEchoStartTLS_disableHTTP2(t *testing.T) {
+	cert, key := testCertificate(t)
+	e := newTestEcho()
+	e.DisableHTTP2 = true
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, c.Request().Proto)
+	})
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.StartTLS("127.0.0.1:0", cert, key)
+	}()
+	if !assert.NoError(t, waitForServerStart(e, errCh, true)) {
+		return
+	}
+
+	res, err := tlsClient().Get("https://" + e.TLSListenerAddr().String())
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "HTTP/1.1", string(body))
+	}
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
+
+func TestEchoStartTLS_error(t *testing.T) {
+	cert, key := testCertificate(t)
+	e := newTestEcho()
+
+	assert.Equal(t, ErrInvalidCertOrKeyType, e.StartTLS(":0", 1, key))
+	assert.Equal(t, ErrInvalidCertOrKeyType, e.StartTLS(":0", cert, nil))
+	assert.Error(t, e.StartTLS(":0", "does-not-exist.pem", key))
+	assert.Error(t, e.StartTLS(":0", cert, []byte("invalid")))
+	assert.Nil(t, e.TLSListenerAddr())
+}
+
+func TestEchoStartTLSAndStart(t *testing.T) {
+	cert, key := testCertificate(t)
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, c.Scheme())
+	})
+
+	errTLSCh := make(chan error)
+	go func() {
+		errTLSCh <- e.StartTLS("127.0.0.1:0", cert, key)
+	}()
+	assert.NoError(t, waitForServerStart(e, errTLSCh, true))
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+
+	for scheme, addr := range map[string]net.Addr{"http": e.ListenerAddr(), "https": e.TLSListenerAddr()} {
+		res, err := tlsClient().Get(scheme + "://" + addr.String())
+		if assert.NoError(t, err) {
+			body, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			assert.Equal(t, scheme, string(body))
+		}
+	}
+
+	// Close stops both servers
+	assert.NoError(t, e.Close())
+	assert.Equal(t, http.ErrServerClosed, <-errTLSCh)
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
+
+func TestEchoStartAutoTLS(t *testing.T) {
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "secure")
+	})
+
+	// HTTP-01 challenges are answered by the plain HTTP server
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	if !assert.NoError(t, waitForServerStart(e, errCh, false)) {
+		return
+	}
+	defer e.Close()
+
+	ca := newTestACMEServer(map[string]string{"example.test": e.ListenerAddr().String()})
+	defer ca.Close()
+
+	cacheDir := t.TempDir()
+	e.AutoTLSManager.Client = &acme.Client{DirectoryURL: ca.URL + "/directory"}
+	e.AutoTLSManager.Cache = autocert.DirCache(cacheDir)
+	e.AutoTLSManager.HostPolicy = autocert.HostWhitelist("example.test")
+
+	errTLSCh := make(chan error)
+	go func() {
+		errTLSCh <- e.StartAutoTLS("127.0.0.1:0")
+	}()
+	if !assert.NoError(t, waitForServerStart(e, errTLSCh, true)) {
+		return
+	}
+
+	get := func(serverName string) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: ca.Roots(), ServerName: serverName},
+		}}
+		return client.Get("https://" + e.TLSListenerAddr().String())
+	}
+
+	res, err := get("example.test")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "secure", string(body))
+	}
+
+	// the certificate is kept in the cache
+	_, err = autocert.DirCache(cacheDir).Get(stdContext.Background(), "example.test")
+	assert.NoError(t, err)
+
+	// no certificate is requested for other hosts
+	_, err = get("other.test")
+	assert.Error(t, err)
+
+	// unknown tokens are not found, routes are served as usual
+	req, _ := http.NewRequest(http.MethodGet, "http://"+e.ListenerAddr().String()+acmeChallengePath+"unknown", nil)
+	req.Host = "example.test"
+	res, err = http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	}
+	code, body := request(http.MethodGet, "/", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "secure", body)
+}
+
+func TestEchoStartH2CServer(t *testing.T) {
+	release := make(chan struct{})
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		if c.QueryParam("stream") == "" {
+			return c.String(http.StatusOK, c.Request().Proto)
+		}
+		res := c.Response()
+		res.Header().Set(HeaderContentType, MIMETextPlain)
+		res.Write([]byte("first\n"))
+		res.Flush()
+		<-release
+		res.Write([]byte("second\n"))
+		return nil
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.StartH2CServer("127.0.0.1:0", nil)
+	}()
+	if !assert.NoError(t, waitForServerStart(e, errCh, false)) {
+		return
+	}
+	defer e.Close()
+	addr := e.ListenerAddr().String()
+
+	// prior knowledge
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	res, err := client.Get("http://" + addr)
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "HTTP/2.0", string(body))
+	}
+
+	// flushed data is sent while the handler is still running
+	res, err = client.Get("http://" + addr + "/?stream=1")
+	if !assert.NoError(t, err) {
+		close(release)
+		return
+	}
+	br := bufio.NewReader(res.Body)
+	line, err := br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", line)
+	close(release)
+	line, err = br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", line)
+	res.Body.Close()
+
+	// HTTP/1.1 clients are served as usual
+	res, err = http.Get("http://" + addr)
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "HTTP/1.1", string(body))
+	}
+}
+
+func TestEchoStartH2CServer_upgrade(t *testing.T) {
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.StartH2CServer("127.0.0.1:0", &http2.Server{})
+	}()
+	if !assert.NoError(t, waitForServerStart(e, errCh, false)) {
+		return
+	}
+	defer e.Close()
+
+	conn, err := net.Dial("tcp", e.ListenerAddr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// An empty SETTINGS payload is encoded as an empty HTTP2-Settings header.
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n\r\n")
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+
+	// the upgraded request is answered on stream 1
+	rw := bufio.NewReadWriter(br, bufio.NewWriter(conn))
+	rw.WriteString(http2.ClientPreface)
+	framer := http2.NewFramer(rw, rw)
+	assert.NoError(t, framer.WriteSettings())
+	rw.Flush()
+
+	var status, body string
+	for body == "" {
+		f, err := framer.ReadFrame()
+		if !assert.NoError(t, err) {
+			return
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+				rw.Flush()
+			}
+		case *http2.HeadersFrame:
+			assert.Equal(t, uint32(1), f.StreamID)
+			fields, err := hpack.NewDecoder(4096, nil).DecodeFull(f.HeaderBlockFragment())
+			assert.NoError(t, err)
+			for _, hf := range fields {
+				if hf.Name == ":status" {
+					status = hf.Value
+				}
+			}
+		case *http2.DataFrame:
+			assert.Equal(t, uint32(1), f.StreamID)
+			body = string(f.Data())
+		}
+	}
+	assert.Equal(t, "200", status)
+	assert.Equal(t, "OK", body)
+}
+
 func testMethod(t *testing.T, method, path string, e *Echo) {
 	p := reflect.ValueOf(path)
 	h := reflect.ValueOf(func(c Context) error {
@@ -176,17 +703,17 @@ func TestHTTPError(t *testing.T) {
 
 		assert.Equal(t, "code=400, message=map[code:12]", err.Error())
 	})
-	// t.Run("internal", func(t *testing.T) {
-	// 	err := NewHTTPError(http.StatusBadRequest, map[string]interface{}{
-	// 		"code": 12,
-	// 	})
-	// 	err.SetInternal(errors.New("internal error"))
-	// 	assert.Equal(t, "code=400, message=map[code:12], internal=internal error", err.Error())
-	// })
+	t.Run("internal", func(t *testing.T) {
+		err := NewHTTPError(http.StatusBadRequest, map[string]interface{}{
+			"code": 12,
+		})
+		err.SetInternal(errors.New("internal error"))
+		assert.Equal(t, "code=400, message=map[code:12], internal=internal error", err.Error())
+	})
 }
 
 func TestEchoClose(t *testing.T) {
-	e := New()
+	e := newTestEcho()
 	errCh := make(chan error)
 
 	go func() {
@@ -207,7 +734,7 @@ func TestEchoClose(t *testing.T) {
 }
 
 func TestEchoShutdown(t *testing.T) {
-	e := New()
+	e := newTestEcho()
 	errCh := make(chan error)
 
 	go func() {
@@ -229,9 +756,45 @@ func TestEchoShutdown(t *testing.T) {
 	assert.Equal(t, err.Error(), "http: Server closed")
 }
 
+func TestEchoShutdown_closeWhileDraining(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+	go http.Get("http://" + e.ListenerAddr().String() + "/")
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- e.Shutdown(stdContext.Background())
+	}()
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+
+	// the hung request blocks neither ListenerAddr nor Close
+	assert.NotNil(t, e.ListenerAddr())
+	assert.NoError(t, e.Close())
+	select {
+	case err := <-shutdownErr:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown was not stopped by Close")
+	}
+}
+
 func TestEchoListenerNetworkInvalid(t *testing.T) {
-	e := New()
-	e.ListenerNetwork = "unix"
+	e := newTestEcho()
+	e.ListenerNetwork = "udp"
 
 	// HandlerFunc
 	e.GET("/ok", func(c Context) error {
@@ -242,7 +805,7 @@ func TestEchoListenerNetworkInvalid(t *testing.T) {
 }
 
 func TestEcho_ListenerAddr(t *testing.T) {
-	e := New()
+	e := newTestEcho()
 
 	addr := e.ListenerAddr()
 	assert.Nil(t, addr)
@@ -255,3 +818,217 @@ func TestEcho_ListenerAddr(t *testing.T) {
 	err := waitForServerStart(e, errCh, false)
 	assert.NoError(t, err)
 }
+
+func TestEchoStartUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket file modes are not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "echo")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "echo.sock")
+
+	// stale socket file left behind by a crashed server
+	stale, err := net.Listen("unix", path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	e := newTestEcho()
+	e.ListenerNetwork = "unix"
+	e.UnixSocket = UnixSocketConfig{
+		Mode:  0600,
+		Owner: strconv.Itoa(os.Getuid()),
+		Group: strconv.Itoa(os.Getgid()),
+	}
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Start(path)
+	}()
+	for i := 0; e.ListenerAddr() == nil; i++ {
+		select {
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+		if i > 200 {
+			t.Fatal("server did not start")
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx stdContext.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	res, err := client.Get("http://unix/")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "OK", string(body))
+	}
+
+	// a socket still in use is not removed
+	e2 := newTestEcho()
+	e2.ListenerNetwork = "unix"
+	assert.Error(t, e2.Start(path))
+
+	assert.NoError(t, e.Close())
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEchoStartUnixSocket_invalidOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket ownership is not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "echo")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	e := newTestEcho()
+	e.ListenerNetwork = "unix"
+	e.UnixSocket.Owner = "echo-no-such-user"
+	assert.Error(t, e.Start(filepath.Join(dir, "echo.sock")))
+}
+
+func TestTCPKeepAliveListener(t *testing.T) {
+	testCases := []struct {
+		name      string
+		keepAlive time.Duration
+	}{
+		{name: "default", keepAlive: 0},
+		{name: "custom", keepAlive: 30 * time.Second},
+		{name: "disabled", keepAlive: -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New()
+			e.ListenerKeepAlive = tc.keepAlive
+			l, err := e.newListener("127.0.0.1:0")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer l.Close()
+			assert.IsType(t, &tcpKeepAliveListener{}, l)
+
+			go func() {
+				if c, err := net.Dial("tcp", l.Addr().String()); err == nil {
+					c.Close()
+				}
+			}()
+			c, err := l.Accept()
+			if assert.NoError(t, err) {
+				assert.IsType(t, &net.TCPConn{}, c)
+				c.Close()
+			}
+		})
+	}
+}
+
+func TestSystemdListeners(t *testing.T) {
+	if os.Getenv("ECHO_TEST_SYSTEMD") == "1" {
+		// child process started below
+		os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		e := newTestEcho()
+		e.ListenerKeepAlive = 42 * time.Second
+		ls, err := e.SystemdListeners()
+		if err != nil || len(ls) != 1 {
+			os.Exit(2)
+		}
+		unset := os.Getenv("LISTEN_FDS") == "" && os.Getenv("LISTEN_PID") == ""
+		kl, ok := ls[0].(*tcpKeepAliveListener)
+		keepAlive := ok && kl.keepAlive == e.ListenerKeepAlive
+		e.Listener = ls[0]
+		e.GET("/", func(c Context) error {
+			return c.String(http.StatusOK, fmt.Sprintf("%d %t %t", os.Getpid(), unset, keepAlive))
+		})
+		e.Start("")
+		os.Exit(0)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("socket activation is not supported on windows")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSystemdListeners$")
+	cmd.Env = append(os.Environ(), "ECHO_TEST_SYSTEMD=1", "LISTEN_FDS=1")
+	cmd.ExtraFiles = []*os.File{f}
+	if !assert.NoError(t, cmd.Start()) {
+		return
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	// the kernel queues connections until the child accepts them
+	client := &http.Client{Timeout: 10 * time.Second}
+	res, err := client.Get("http://" + l.Addr().String() + "/")
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, fmt.Sprintf("%d true true", cmd.Process.Pid), string(body))
+}
+
+func TestSystemdListeners_notActivated(t *testing.T) {
+	os.Setenv("LISTEN_PID", "1")
+	os.Setenv("LISTEN_FDS", "1")
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+
+	ls, err := New().SystemdListeners()
+	assert.NoError(t, err)
+	assert.Empty(t, ls)
+	assert.Equal(t, "", os.Getenv("LISTEN_FDS"))
+}
+
+func TestEchoOptionsAllowHeader(t *testing.T) {
+	e := New()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "Echo!")
+	})
+	e.DELETE("/", func(c Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "OPTIONS, DELETE, GET", rec.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "OPTIONS, DELETE, GET", rec.Header().Get(HeaderAllow))
+}
@@ -0,0 +1,77 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupRouteMiddleware(t *testing.T) {
+	e := New()
+	calls := ""
+	mw := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c Context) error {
+				calls += name
+				return next(c)
+			}
+		}
+	}
+
+	g := e.Group("/api", mw("g"))
+	sg := g.Group("/v1", mw("s"))
+	r := sg.POST("/users", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	}, mw("r"))
+	assert.Equal(t, "/api/v1/users", r.Path)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gsr", calls)
+}
+
+func TestGroup_separatePrefixes(t *testing.T) {
+	e := New()
+	mw := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c Context) error {
+				c.Response().Header().Set("X-Group", name)
+				return next(c)
+			}
+		}
+	}
+	h := func(c Context) error {
+		return c.String(http.StatusOK, c.Path())
+	}
+	e.Group("/api", mw("api")).POST("/upload", h)
+	e.Group("/admin", mw("admin")).GET("/upload", h)
+
+	testCases := []struct {
+		method      string
+		path        string
+		expectCode  int
+		expectGroup string
+	}{
+		{method: http.MethodPost, path: "/api/upload", expectCode: http.StatusOK, expectGroup: "api"},
+		{method: http.MethodGet, path: "/admin/upload", expectCode: http.StatusOK, expectGroup: "admin"},
+		{method: http.MethodGet, path: "/api/upload", expectCode: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/admin/upload", expectCode: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/upload", expectCode: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectGroup, rec.Header().Get("X-Group"))
+			if tc.expectCode == http.StatusOK {
+				assert.Equal(t, tc.path, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,13 @@
 package echo
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
 
 type (
 	Logger interface {
@@ -33,4 +40,136 @@ type (
 		// Panicj(j log.JSON)
 		Panicf(format string, args ...interface{})
 	}
+
+	// logger is the default Logger. It writes one line per entry, starting with
+	// the header in which `${time_rfc3339}`, `${level}` and `${prefix}` are
+	// substituted.
+	logger struct {
+		mu     sync.Mutex
+		prefix string
+		header string
+		output io.Writer
+	}
 )
+
+const defaultLoggerHeader = "${time_rfc3339} ${level} ${prefix}:"
+
+func newLogger(prefix string) *logger {
+	return &logger{
+		prefix: prefix,
+		header: defaultLoggerHeader,
+		output: os.Stdout,
+	}
+}
+
+func (l *logger) Output() io.Writer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.output
+}
+
+func (l *logger) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.output = w
+}
+
+func (l *logger) Prefix() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.prefix
+}
+
+func (l *logger) SetPrefix(p string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prefix = p
+}
+
+func (l *logger) SetHeader(h string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.header = h
+}
+
+func (l *logger) Print(i ...interface{}) {
+	l.log("", fmt.Sprint(i...))
+}
+
+func (l *logger) Printf(format string, args ...interface{}) {
+	l.log("", fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Debug(i ...interface{}) {
+	l.log("DEBUG", fmt.Sprint(i...))
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	l.log("DEBUG", fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Info(i ...interface{}) {
+	l.log("INFO", fmt.Sprint(i...))
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.log("INFO", fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Warn(i ...interface{}) {
+	l.log("WARN", fmt.Sprint(i...))
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	l.log("WARN", fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Error(i ...interface{}) {
+	l.log("ERROR", fmt.Sprint(i...))
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	l.log("ERROR", fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Fatal(i ...interface{}) {
+	l.log("FATAL", fmt.Sprint(i...))
+	os.Exit(1)
+}
+
+func (l *logger) Fatalf(format string, args ...interface{}) {
+	l.log("FATAL", fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (l *logger) Panic(i ...interface{}) {
+	msg := fmt.Sprint(i...)
+	l.log("PANIC", msg)
+	panic(msg)
+}
+
+func (l *logger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log("PANIC", msg)
+	panic(msg)
+}
+
+func (l *logger) log(level, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := new(strings.Builder)
+	if level != "" {
+		b.WriteString(strings.NewReplacer(
+			"${time_rfc3339}", time.Now().Format(time.RFC3339),
+			"${level}", level,
+			"${prefix}", l.prefix,
+		).Replace(l.header))
+		b.WriteByte(' ')
+	}
+	b.WriteString(msg)
+	if !strings.HasSuffix(msg, "\n") {
+		b.WriteByte('\n')
+	}
+	io.WriteString(l.output, b.String())
+}
@@ -0,0 +1,28 @@
+package echo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	l := newLogger("test")
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+	assert.Equal(t, buf, l.Output())
+	assert.Equal(t, "test", l.Prefix())
+
+	l.SetHeader("${level} ${prefix}:")
+	l.Errorf("oops %d", 1)
+	l.Print("plain")
+	assert.Equal(t, "ERROR test: oops 1\nplain\n", buf.String())
+
+	buf.Reset()
+	l.SetPrefix("echo")
+	assert.PanicsWithValue(t, "bad", func() {
+		l.Panic("bad")
+	})
+	assert.Equal(t, "PANIC echo: bad\n", buf.String())
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// BasicAuthConfig defines the config for BasicAuth middleware.
+	BasicAuthConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Validator is a function to validate BasicAuth credentials.
+		// Required.
+		Validator BasicAuthValidator
+
+		// Realm is a string to define realm attribute of BasicAuth.
+		// Optional. Default value "Restricted".
+		Realm string
+
+		// ContextKey is the key the authenticated username is stored under in
+		// the context.
+		// Optional. Default value "user".
+		ContextKey string
+	}
+
+	// BasicAuthValidator defines a function to validate BasicAuth credentials.
+	BasicAuthValidator func(user string, password string, c echo.Context) (bool, error)
+)
+
+const (
+	basic        = "basic"
+	defaultRealm = "Restricted"
+)
+
+var (
+	// DefaultBasicAuthConfig is the default BasicAuth middleware config.
+	DefaultBasicAuthConfig = BasicAuthConfig{
+		Skipper:    DefaultSkipper,
+		Realm:      defaultRealm,
+		ContextKey: "user",
+	}
+)
+
+// BasicAuth returns an BasicAuth middleware.
+//
+// For valid credentials it stores the username in the context and calls the
+// next handler. For missing or invalid credentials, it sends "401 - Unauthorized"
+// response along with a `WWW-Authenticate` challenge.
+func BasicAuth(fn BasicAuthValidator) echo.MiddlewareFunc {
+	c := DefaultBasicAuthConfig
+	c.Validator = fn
+	return BasicAuthWithConfig(c)
+}
+
+// BasicAuthWithConfig returns an BasicAuth middleware with config.
+// See `BasicAuth()`.
+func BasicAuthWithConfig(config BasicAuthConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Validator == nil {
+		panic("echo: basic-auth middleware requires a validator function")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultBasicAuthConfig.Skipper
+	}
+	if config.Realm == "" {
+		config.Realm = DefaultBasicAuthConfig.Realm
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultBasicAuthConfig.ContextKey
+	}
+	challenge := "Basic realm=" + strconv.Quote(config.Realm)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			var lastError error
+			l := len(basic)
+			for i, auth := range c.Request().Header.Values(echo.HeaderAuthorization) {
+				if i >= extractorLimit {
+					break
+				}
+				if !(len(auth) > l+1 && strings.EqualFold(auth[:l], basic) && auth[l] == ' ') {
+					continue
+				}
+
+				b, err := base64.StdEncoding.DecodeString(auth[l+1:])
+				if err != nil {
+					lastError = echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+					continue
+				}
+				cred := string(b)
+				i := strings.IndexByte(cred, ':')
+				if i < 0 {
+					continue
+				}
+				user := cred[:i]
+				valid, err := config.Validator(user, cred[i+1:], c)
+				if err != nil {
+					return err
+				}
+				if valid {
+					c.Set(config.ContextKey, user)
+					return next(c)
+				}
+			}
+			if lastError != nil {
+				return lastError
+			}
+
+			// Need to return `401` for browsers to pop-up login box.
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
+			return echo.ErrUnauthorized
+		}
+	}
+}
+
+// SecureCompare reports whether given equals actual in constant time. The
+// values are hashed first so the time taken does not leak the length of actual
+// either.
+func SecureCompare(given, actual string) bool {
+	g := sha256.Sum256([]byte(given))
+	a := sha256.Sum256([]byte(actual))
+	return subtle.ConstantTimeCompare(g[:], a[:]) == 1
+}
+
+// BasicAuthAccounts returns a BasicAuthValidator checking credentials against
+// the given username to password map with `SecureCompare()`.
+func BasicAuthAccounts(accounts map[string]string) BasicAuthValidator {
+	return func(user, password string, c echo.Context) (bool, error) {
+		valid := false
+		// Compare against every account so the time taken does not reveal
+		// whether the username exists.
+		for u, p := range accounts {
+			if SecureCompare(user, u) && SecureCompare(password, p) {
+				valid = true
+			}
+		}
+		return valid, nil
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicAuth(t *testing.T) {
+	validator := BasicAuthAccounts(map[string]string{"joe": "secret"})
+	basicAuth := func(user, password string) string {
+		return basic + " " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	}
+
+	testCases := []struct {
+		name            string
+		givenAuth       []string
+		givenRealm      string
+		expectErr       error
+		expectErrCode   int
+		expectChallenge string
+		expectUser      string
+	}{
+		{
+			name:       "ok",
+			givenAuth:  []string{basicAuth("joe", "secret")},
+			expectUser: "joe",
+		},
+		{
+			name:       "ok, case insensitive scheme",
+			givenAuth:  []string{"BASIC " + base64.StdEncoding.EncodeToString([]byte("joe:secret"))},
+			expectUser: "joe",
+		},
+		{
+			name:       "ok, multiple headers",
+			givenAuth:  []string{basicAuth("joe", "wrong"), basicAuth("joe", "secret")},
+			expectUser: "joe",
+		},
+		{
+			name:            "nok, invalid password",
+			givenAuth:       []string{basicAuth("joe", "wrong")},
+			expectErr:       echo.ErrUnauthorized,
+			expectChallenge: `Basic realm="Restricted"`,
+		},
+		{
+			name:            "nok, custom realm",
+			givenAuth:       []string{basicAuth("jon", "secret")},
+			givenRealm:      "my realm",
+			expectErr:       echo.ErrUnauthorized,
+			expectChallenge: `Basic realm="my realm"`,
+		},
+		{
+			name:            "nok, missing header",
+			expectErr:       echo.ErrUnauthorized,
+			expectChallenge: `Basic realm="Restricted"`,
+		},
+		{
+			name:            "nok, other scheme",
+			givenAuth:       []string{"Bearer token"},
+			expectErr:       echo.ErrUnauthorized,
+			expectChallenge: `Basic realm="Restricted"`,
+		},
+		{
+			name:          "nok, invalid base64",
+			givenAuth:     []string{"basic invalid!"},
+			expectErrCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, a := range tc.givenAuth {
+				req.Header.Add(echo.HeaderAuthorization, a)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mw := BasicAuthWithConfig(BasicAuthConfig{Validator: validator, Realm: tc.givenRealm})
+			err := mw(func(c echo.Context) error {
+				return c.String(http.StatusOK, c.Get("user").(string))
+			})(c)
+
+			if tc.expectErrCode != 0 {
+				var he *echo.HTTPError
+				if assert.ErrorAs(t, err, &he) {
+					assert.Equal(t, tc.expectErrCode, he.Code)
+				}
+				return
+			}
+			assert.Equal(t, tc.expectErr, err)
+			assert.Equal(t, tc.expectChallenge, rec.Header().Get(echo.HeaderWWWAuthenticate))
+			if tc.expectUser != "" {
+				assert.Equal(t, tc.expectUser, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBasicAuth_validatorError(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("joe", "secret")
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	mw := BasicAuth(func(user, password string, c echo.Context) (bool, error) {
+		return false, errors.New("db down")
+	})
+	assert.EqualError(t, mw(func(c echo.Context) error { return nil })(c), "db down")
+
+	assert.Panics(t, func() {
+		BasicAuth(nil)
+	})
+}
+
+func TestSecureCompare(t *testing.T) {
+	assert.True(t, SecureCompare("secret", "secret"))
+	assert.False(t, SecureCompare("secret", "secre"))
+	assert.False(t, SecureCompare("", "secret"))
+	assert.True(t, SecureCompare("", ""))
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	e := echo.New()
+	hw := []byte("Hello, World!")
+	h := func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(body))
+	}
+
+	// Based on content length (within limit)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hw))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, BodyLimit("2M")(h)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, hw, rec.Body.Bytes())
+	}
+
+	// Based on content length (overlimit)
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hw))
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	called := false
+	err := BodyLimit("2B")(func(c echo.Context) error {
+		called = true
+		return nil
+	})(c)
+	assert.Equal(t, echo.ErrStatusRequestEntityTooLarge, err)
+	assert.False(t, called)
+
+	// Based on content read (within limit)
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hw))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, BodyLimit("2M")(h)(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Hello, World!", rec.Body.String())
+	}
+
+	// Based on content read (overlimit)
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hw))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	err = BodyLimit("2B")(h)(c)
+	assert.Equal(t, echo.ErrStatusRequestEntityTooLarge, err)
+}
+
+func TestBodyLimit_PerRouteAndGroup(t *testing.T) {
+	e := echo.New()
+	h := func(c echo.Context) error {
+		if _, err := io.ReadAll(c.Request().Body); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+	g := e.Group("/upload", BodyLimit("4B"))
+	g.POST("/small", h)
+
+	req := httptest.NewRequest(http.MethodPost, "/upload/small", bytes.NewReader([]byte("too long")))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	e = echo.New()
+	e.Use(BodyLimit("4B"))
+	e.POST("/large", h, BodyLimit("1K"))
+
+	// the global limit applies first
+	req = httptest.NewRequest(http.MethodPost, "/large", bytes.NewReader([]byte("too long")))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	e = echo.New()
+	e.POST("/large", h, BodyLimit("1K"))
+
+	req = httptest.NewRequest(http.MethodPost, "/large", bytes.NewReader([]byte("fits in 1K")))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestBodyLimitWithConfig_invalidLimit(t *testing.T) {
+	assert.PanicsWithError(t, "echo: invalid body-limit=2X", func() {
+		BodyLimitWithConfig(BodyLimitConfig{Limit: "2X"})
+	})
+}
+
+func TestParseBytes(t *testing.T) {
+	testCases := []struct {
+		given     string
+		expect    int64
+		expectErr bool
+	}{
+		{given: "100", expect: 100},
+		{given: "100B", expect: 100},
+		{given: "512K", expect: 512 << 10},
+		{given: "512kb", expect: 512 << 10},
+		{given: "2M", expect: 2 << 20},
+		{given: "1.5G", expect: 3 << 29},
+		{given: "1 T", expect: 1 << 40},
+		{given: "1P", expect: 1 << 50},
+		{given: "", expectErr: true},
+		{given: "M", expectErr: true},
+		{given: "1X", expectErr: true},
+		{given: "1.2.3K", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.given, func(t *testing.T) {
+			v, err := parseBytes(tc.given)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, v)
+		})
+	}
+}
+
+func TestBodyLimit_groups(t *testing.T) {
+	e := echo.New()
+	h := func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(body))
+	}
+	e.Group("/small", BodyLimit("4B")).POST("/upload", h)
+	e.Group("/large", BodyLimit("1K")).POST("/upload", h)
+
+	testCases := []struct {
+		path       string
+		expectCode int
+	}{
+		{path: "/small/upload", expectCode: http.StatusRequestEntityTooLarge},
+		{path: "/large/upload", expectCode: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader([]byte("Hello, World!")))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// CompressConfig defines the config for Compress middleware.
+	CompressConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Level is the compression level passed to the encoders.
+		// Optional. Default value -1 (default compression of the encoder).
+		Level int
+
+		// MinLength is the minimum length of the response body in bytes for
+		// it to be compressed. Shorter responses are sent as they are.
+		// Optional. Default value 0, every response is compressed.
+		MinLength int
+
+		// Encodings lists the content codings the middleware may use, in order
+		// of preference when the client accepts several with the same q-value.
+		// Every entry must be registered, see `RegisterCompressEncoder()`.
+		// Optional. Default value []string{"gzip", "deflate"}.
+		Encodings []string
+
+		// SkipContentTypes lists media types which are already compressed and
+		// are sent as they are. An entry ending with "/" matches every subtype.
+		// Optional. Default value DefaultCompressConfig.SkipContentTypes.
+		SkipContentTypes []string
+	}
+
+	// CompressWriter is a writer compressing into an underlying io.Writer that
+	// can be reused for another target with Reset.
+	CompressWriter interface {
+		io.WriteCloser
+		Flush() error
+		Reset(w io.Writer)
+	}
+
+	// CompressEncoderFunc creates a CompressWriter writing to w at the given
+	// level.
+	CompressEncoderFunc func(w io.Writer, level int) (CompressWriter, error)
+
+	compressEncoder struct {
+		name string
+		pool *sync.Pool
+	}
+
+	compressResponseWriter struct {
+		http.ResponseWriter
+		encoder           *compressEncoder
+		writer            CompressWriter
+		skipContentTypes  []string
+		buffer            *bytes.Buffer
+		minLength         int
+		code              int
+		wroteHeader       bool
+		wroteBody         bool
+		minLengthExceeded bool
+		passthrough       bool
+	}
+)
+
+var (
+	// DefaultCompressConfig is the default Compress middleware config.
+	DefaultCompressConfig = CompressConfig{
+		Skipper:   DefaultSkipper,
+		Level:     -1,
+		MinLength: 0,
+		Encodings: []string{"gzip", "deflate"},
+		SkipContentTypes: []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+			"video/", "audio/", "font/woff", "font/woff2",
+			"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+			"application/x-xz", "application/x-7z-compressed", "application/x-rar-compressed",
+		},
+	}
+
+	compressEncoders = struct {
+		sync.RWMutex
+		m map[string]CompressEncoderFunc
+	}{
+		m: map[string]CompressEncoderFunc{
+			"gzip": func(w io.Writer, level int) (CompressWriter, error) {
+				return gzip.NewWriterLevel(w, level)
+			},
+			"deflate": func(w io.Writer, level int) (CompressWriter, error) {
+				return flate.NewWriter(w, level)
+			},
+		},
+	}
+)
+
+// RegisterCompressEncoder makes a content coding, e.g. "br", available to the
+// Compress middleware. Registering an existing name replaces its encoder.
+// Encoders have to be registered before the middleware is created.
+func RegisterCompressEncoder(encoding string, fn CompressEncoderFunc) {
+	compressEncoders.Lock()
+	defer compressEncoders.Unlock()
+	compressEncoders.m[strings.ToLower(encoding)] = fn
+}
+
+// Compress returns a middleware which compresses HTTP responses with the
+// content coding preferred by the client.
+func Compress() echo.MiddlewareFunc {
+	return CompressWithConfig(DefaultCompressConfig)
+}
+
+// CompressWithConfig returns a Compress middleware with config.
+// See: `Compress()`.
+func CompressWithConfig(config CompressConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCompressConfig.Skipper
+	}
+	if config.Level == 0 {
+		config.Level = DefaultCompressConfig.Level
+	}
+	if config.MinLength < 0 {
+		config.MinLength = DefaultCompressConfig.MinLength
+	}
+	if len(config.Encodings) == 0 {
+		config.Encodings = DefaultCompressConfig.Encodings
+	}
+	if config.SkipContentTypes == nil {
+		config.SkipContentTypes = DefaultCompressConfig.SkipContentTypes
+	}
+
+	encoders := make([]*compressEncoder, 0, len(config.Encodings))
+	for _, name := range config.Encodings {
+		enc, err := newCompressEncoder(strings.ToLower(name), config.Level)
+		if err != nil {
+			panic(err)
+		}
+		encoders = append(encoders, enc)
+	}
+	bpool := &sync.Pool{
+		New: func() interface{} {
+			return &bytes.Buffer{}
+		},
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			enc := negotiateEncoding(c.Request().Header.Get(echo.HeaderAcceptEncoding), encoders)
+			if enc == nil {
+				return next(c)
+			}
+
+			w := enc.pool.Get().(CompressWriter)
+			rw := res.Writer
+			w.Reset(rw)
+			buf := bpool.Get().(*bytes.Buffer)
+			buf.Reset()
+
+			cw := &compressResponseWriter{
+				ResponseWriter:   rw,
+				encoder:          enc,
+				writer:           w,
+				skipContentTypes: config.SkipContentTypes,
+				buffer:           buf,
+				minLength:        config.MinLength,
+			}
+			defer func() {
+				// Writes after the middleware, e.g. by the error handler, must not
+				// reach the pooled writer another request may own by then.
+				res.Writer = rw
+
+				// The response may not have been written to the client yet:
+				// a) the handler only set a status code (redirects, errors etc.) or
+				//    returned an error, so the status has to be written now and the
+				//    response is given back to the error handler untouched.
+				// b) the body is shorter than MinLength and still sits in the buffer.
+				if !cw.wroteBody {
+					if cw.wroteHeader {
+						rw.WriteHeader(cw.code)
+					}
+					w.Reset(io.Discard)
+				} else if !cw.minLengthExceeded && !cw.passthrough {
+					if cw.wroteHeader {
+						rw.WriteHeader(cw.code)
+					}
+					buf.WriteTo(rw)
+					w.Reset(io.Discard)
+				}
+				if cw.passthrough {
+					w.Reset(io.Discard)
+				}
+				w.Close()
+				bpool.Put(buf)
+				enc.pool.Put(w)
+			}()
+			res.Writer = cw
+			return next(c)
+		}
+	}
+}
+
+func newCompressEncoder(name string, level int) (*compressEncoder, error) {
+	compressEncoders.RLock()
+	fn, ok := compressEncoders.m[name]
+	compressEncoders.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("echo: compress encoding %q is not registered", name)
+	}
+	// Check the level once up front so creating pooled writers cannot fail.
+	if _, err := fn(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &compressEncoder{
+		name: name,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				w, _ := fn(io.Discard, level)
+				return w
+			},
+		},
+	}, nil
+}
+
+// negotiateEncoding picks the encoder with the highest q-value in the
+// Accept-Encoding header. Ties are won by the encoder listed first.
+func negotiateEncoding(acceptEncoding string, encoders []*compressEncoder) *compressEncoder {
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	qvalues := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseQValue(part)
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		if prev, ok := qvalues[name]; !ok || q > prev {
+			qvalues[name] = q
+		}
+	}
+
+	var best *compressEncoder
+	bestQ := 0.0
+	for _, enc := range encoders {
+		q, ok := qvalues[enc.name]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+func parseQValue(s string) (string, float64) {
+	name, params := s, ""
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		name, params = s[:i], s[i+1:]
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	q := 1.0
+	for _, p := range strings.Split(params, ";") {
+		p = strings.TrimSpace(p)
+		if len(p) > 2 && (p[0] == 'q' || p[0] == 'Q') && p[1] == '=' {
+			v, err := strconv.ParseFloat(p[2:], 64)
+			if err != nil {
+				return name, 0
+			}
+			q = v
+		}
+	}
+	return name, q
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < http.StatusOK {
+		// Responses without a body are never compressed.
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.Header().Del(echo.HeaderContentLength) // The length changes with compression
+	w.wroteHeader = true
+
+	// Delay writing of the header until we know if we'll actually compress the response
+	w.code = code
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.Header().Get(echo.HeaderContentType) == "" {
+		w.Header().Set(echo.HeaderContentType, http.DetectContentType(b))
+	}
+	if !w.wroteBody {
+		if w.startBody(); w.passthrough {
+			return w.ResponseWriter.Write(b)
+		}
+	}
+
+	if !w.minLengthExceeded {
+		n, err := w.buffer.Write(b)
+		if w.buffer.Len() >= w.minLength {
+			if err := w.startCompression(); err != nil {
+				return 0, err
+			}
+		}
+		return n, err
+	}
+	return w.writer.Write(b)
+}
+
+// startBody decides on the first write or flush of the body whether the
+// response is already compressed and has to be sent as it is.
+func (w *compressResponseWriter) startBody() {
+	w.wroteBody = true
+	if w.Header().Get(echo.HeaderContentEncoding) != "" || w.skipContentType() {
+		w.passthrough = true
+		if w.wroteHeader {
+			w.ResponseWriter.WriteHeader(w.code)
+		}
+	}
+}
+
+// startCompression commits the response as compressed and compresses
+// everything buffered so far.
+func (w *compressResponseWriter) startCompression() error {
+	w.minLengthExceeded = true
+	w.Header().Del(echo.HeaderContentLength)
+	w.Header().Set(echo.HeaderContentEncoding, w.encoder.name)
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	_, err := w.writer.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+func (w *compressResponseWriter) skipContentType() bool {
+	ct := w.Header().Get(echo.HeaderContentType)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	for _, s := range w.skipContentTypes {
+		if ct == s || (strings.HasSuffix(s, "/") && strings.HasPrefix(ct, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush sends everything written so far to the client. Flushing before
+// MinLength is reached starts compression anyway as the response is streamed.
+func (w *compressResponseWriter) Flush() {
+	if !w.passthrough && !w.wroteBody {
+		w.startBody()
+	}
+	if !w.passthrough {
+		if !w.minLengthExceeded {
+			w.startCompression()
+		}
+		w.writer.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("echo: response writer does not implement http.Hijacker")
+}
+
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
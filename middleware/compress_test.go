@@ -0,0 +1,329 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// Skip if no Accept-Encoding header
+	h := Compress()(func(c echo.Context) error {
+		c.Response().Write([]byte("test")) // For Content-Type sniffing
+		return nil
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Equal(t, echo.HeaderAcceptEncoding, rec.Header().Get(echo.HeaderVary))
+
+	// Gzip
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, h(c))
+	assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMETextPlain)
+	r, err := gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		buf := new(bytes.Buffer)
+		defer r.Close()
+		buf.ReadFrom(r)
+		assert.Equal(t, "test", buf.String())
+	}
+}
+
+func TestCompress_Negotiation(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding string
+		expect         string
+	}{
+		{acceptEncoding: "gzip, deflate", expect: "gzip"},
+		{acceptEncoding: "deflate, gzip", expect: "gzip"},
+		{acceptEncoding: "gzip;q=0.5, deflate", expect: "deflate"},
+		{acceptEncoding: "gzip;q=0, deflate;q=0.1", expect: "deflate"},
+		{acceptEncoding: "br, *;q=0.2", expect: "gzip"},
+		{acceptEncoding: "gzip;q=0", expect: ""},
+		{acceptEncoding: "identity", expect: ""},
+		{acceptEncoding: "*;q=0", expect: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.acceptEncoding, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAcceptEncoding, tc.acceptEncoding)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := Compress()(func(c echo.Context) error {
+				return c.String(http.StatusOK, "test")
+			})
+			assert.NoError(t, h(c))
+			assert.Equal(t, tc.expect, rec.Header().Get(echo.HeaderContentEncoding))
+
+			var body io.Reader = rec.Body
+			switch tc.expect {
+			case "gzip":
+				body, _ = gzip.NewReader(rec.Body)
+			case "deflate":
+				body = flate.NewReader(rec.Body)
+			}
+			b, err := io.ReadAll(body)
+			assert.NoError(t, err)
+			assert.Equal(t, "test", string(b))
+		})
+	}
+}
+
+func TestCompressWithConfig_MinLength(t *testing.T) {
+	e := echo.New()
+	mw := CompressWithConfig(CompressConfig{MinLength: 10})
+
+	for body, compressed := range map[string]bool{"short": false, "long enough body": true} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := mw(func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderContentLength, "99")
+			return c.String(http.StatusCreated, body)
+		})
+		assert.NoError(t, h(c))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentLength))
+		if compressed {
+			assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+			r, err := gzip.NewReader(rec.Body)
+			assert.NoError(t, err)
+			b, _ := io.ReadAll(r)
+			assert.Equal(t, body, string(b))
+		} else {
+			assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+			assert.Equal(t, body, rec.Body.String())
+		}
+	}
+}
+
+func TestCompress_SkipsCompressedContent(t *testing.T) {
+	e := echo.New()
+	mw := Compress()
+
+	testCases := []struct {
+		name    string
+		handler echo.HandlerFunc
+	}{
+		{
+			name: "already encoded",
+			handler: func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderContentEncoding, "br")
+				return c.Blob(http.StatusOK, echo.MIMETextPlain, []byte("raw"))
+			},
+		},
+		{
+			name: "compressed media type",
+			handler: func(c echo.Context) error {
+				return c.Blob(http.StatusOK, "image/png", []byte("raw"))
+			},
+		},
+		{
+			name: "compressed media type by prefix",
+			handler: func(c echo.Context) error {
+				return c.Blob(http.StatusOK, "video/mp4", []byte("raw"))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.NoError(t, mw(tc.handler)(c))
+			assert.NotEqual(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+			assert.Equal(t, "raw", rec.Body.String())
+		})
+	}
+}
+
+func TestCompress_NoContentAndErrors(t *testing.T) {
+	e := echo.New()
+	e.Use(Compress())
+	e.GET("/empty", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, 0, rec.Body.Len())
+
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Contains(t, rec.Body.String(), "Not Found")
+}
+
+func TestCompress_Flush(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := CompressWithConfig(CompressConfig{MinLength: 1024})(func(c echo.Context) error {
+		c.Response().Write([]byte("chunk"))
+		c.Response().Flush()
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+		assert.NotZero(t, rec.Body.Len())
+		_, err := c.Response().Write([]byte(" more"))
+		return err
+	})
+	assert.NoError(t, h(c))
+
+	r, err := gzip.NewReader(rec.Body)
+	assert.NoError(t, err)
+	b, _ := io.ReadAll(r)
+	assert.Equal(t, "chunk more", string(b))
+}
+
+func TestCompress_FlushSkipsCompressedContent(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{name: "skipped content type", header: echo.HeaderContentType, value: "image/png"},
+		{name: "content encoding", header: echo.HeaderContentEncoding, value: "br"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := Compress()(func(c echo.Context) error {
+				c.Response().Header().Set(tc.header, tc.value)
+				c.Response().WriteHeader(http.StatusAccepted)
+				c.Response().Flush() // headers first
+				_, err := c.Response().Write([]byte("raw"))
+				return err
+			})
+			assert.NoError(t, h(c))
+
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.True(t, rec.Flushed)
+			assert.NotEqual(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+			assert.Equal(t, "raw", rec.Body.String())
+		})
+	}
+}
+
+func TestCompress_restoresWriter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "compressed", contentType: echo.MIMETextPlain, body: "test"},
+		{name: "passthrough", contentType: "image/png", body: "test"},
+		{name: "no body"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := Compress()(func(c echo.Context) error {
+				if tc.body == "" {
+					return nil
+				}
+				c.Response().Header().Set(echo.HeaderContentType, tc.contentType)
+				_, err := c.Response().Write([]byte(tc.body))
+				return err
+			})
+			assert.NoError(t, h(c))
+			assert.Equal(t, rec, c.Response().Writer)
+		})
+	}
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (u *upperWriter) Write(b []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(b))
+}
+func (u *upperWriter) Close() error      { return nil }
+func (u *upperWriter) Flush() error      { return nil }
+func (u *upperWriter) Reset(w io.Writer) { u.w = w }
+
+func TestRegisterCompressEncoder(t *testing.T) {
+	RegisterCompressEncoder("x-upper", func(w io.Writer, level int) (CompressWriter, error) {
+		return &upperWriter{w: w}, nil
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip;q=0.5, x-upper")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := CompressWithConfig(CompressConfig{
+		Encodings: []string{"gzip", "x-upper"},
+	})(func(c echo.Context) error {
+		return c.String(http.StatusOK, "shout")
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, "x-upper", rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, "SHOUT", rec.Body.String())
+
+	assert.PanicsWithError(t, `echo: compress encoding "zstd" is not registered`, func() {
+		CompressWithConfig(CompressConfig{Encodings: []string{"zstd"}})
+	})
+}
+
+func BenchmarkCompress(b *testing.B) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	h := Compress()(func(c echo.Context) error {
+		return c.String(http.StatusOK, strings.Repeat("test", 256))
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := e.NewContext(req, httptest.NewRecorder())
+		h(c)
+	}
+}
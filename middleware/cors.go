@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// CORSConfig defines the config for CORS middleware.
+	CORSConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// AllowOrigins determines the value of the Access-Control-Allow-Origin
+		// response header. This header defines a list of origins that may access the
+		// resource. The wildcard characters '*' and '?' match any run of host
+		// characters and a single host character, so that `https://*.example.com`
+		// allows every subdomain of example.com.
+		//
+		// Security: use extreme caution when handling the origin, and carefully
+		// validate any logic. Remember that attackers may register hostile domain names.
+		// See https://blog.portswigger.net/2016/10/exploiting-cors-misconfigurations-for.html
+		//
+		// Optional. Default value []string{"*"}.
+		AllowOrigins []string
+
+		// AllowOriginFunc is a custom function to validate the origin. It takes the
+		// origin as an argument and returns true if allowed or false otherwise. If
+		// an error is returned, it is returned by the handler. If this option is
+		// set, AllowOrigins is ignored.
+		//
+		// Optional.
+		AllowOriginFunc func(origin string) (bool, error)
+
+		// AllowMethods determines the value of the Access-Control-Allow-Methods
+		// response header. This header specified the list of methods allowed when
+		// accessing the resource. This is used in response to a preflight request.
+		//
+		// Optional. Default value is the methods the router has registered for
+		// the request path, or DefaultCORSConfig.AllowMethods if that is unknown.
+		AllowMethods []string
+
+		// AllowHeaders determines the value of the Access-Control-Allow-Headers
+		// response header. This header is used in response to a preflight request to
+		// indicate which HTTP headers can be used when making the actual request.
+		//
+		// Optional. Default value echoes the Access-Control-Request-Headers of
+		// the preflight request.
+		AllowHeaders []string
+
+		// AllowCredentials determines the value of the
+		// Access-Control-Allow-Credentials response header. This header indicates
+		// whether or not the response to the request can be exposed when the
+		// credentials mode (Request.credentials) is true. When used as part of a
+		// response to a preflight request, this indicates whether or not the actual
+		// request can be made using credentials.
+		//
+		// Optional. Default value false.
+		AllowCredentials bool
+
+		// UnsafeWildcardOriginWithAllowCredentials allows the wildcard origin `*`
+		// to be combined with AllowCredentials by reflecting the request origin.
+		// This is a security hole for any site serving private data.
+		//
+		// Optional. Default value false.
+		UnsafeWildcardOriginWithAllowCredentials bool
+
+		// ExposeHeaders determines the value of Access-Control-Expose-Headers, which
+		// defines a list of headers that clients are allowed to access.
+		//
+		// Optional. Default value []string{}.
+		ExposeHeaders []string
+
+		// MaxAge determines the value of the Access-Control-Max-Age response header.
+		// This header indicates how long (in seconds) the results of a preflight
+		// request can be cached. A negative value sends "0" which disables caching.
+		//
+		// Optional. Default value 0, the header is not sent.
+		MaxAge int
+	}
+)
+
+var (
+	// DefaultCORSConfig is the default CORS middleware config.
+	DefaultCORSConfig = CORSConfig{
+		Skipper:      DefaultSkipper,
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+	}
+)
+
+// CORS returns a Cross-Origin Resource Sharing (CORS) middleware.
+// See: https://developer.mozilla.org/en/docs/Web/HTTP/Access_control_CORS
+func CORS() echo.MiddlewareFunc {
+	return CORSWithConfig(DefaultCORSConfig)
+}
+
+// CORSWithConfig returns a CORS middleware with config.
+// See: `CORS()`.
+func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCORSConfig.Skipper
+	}
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = DefaultCORSConfig.AllowOrigins
+	}
+	hasCustomAllowMethods := true
+	if len(config.AllowMethods) == 0 {
+		hasCustomAllowMethods = false
+		config.AllowMethods = DefaultCORSConfig.AllowMethods
+	}
+
+	var allowOriginPatterns []*regexp.Regexp
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" || !strings.ContainsAny(origin, "*?") {
+			continue
+		}
+		pattern := regexp.QuoteMeta(origin)
+		// A wildcard must not reach over the scheme, port or path separators,
+		// otherwise `https://*.example.com` would allow `https://evil.com/.example.com`.
+		pattern = strings.Replace(pattern, "\\*", "[^/:]*", -1)
+		pattern = strings.Replace(pattern, "\\?", "[^/:]", -1)
+		allowOriginPatterns = append(allowOriginPatterns, regexp.MustCompile("^"+pattern+"$"))
+	}
+
+	allowMethods := strings.Join(config.AllowMethods, ",")
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+	maxAge := "0"
+	if config.MaxAge > 0 {
+		maxAge = strconv.Itoa(config.MaxAge)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			origin := req.Header.Get(echo.HeaderOrigin)
+			allowOrigin := ""
+
+			res.Header().Add(echo.HeaderVary, echo.HeaderOrigin)
+
+			// A preflight is an OPTIONS request asking for permission to use a method.
+			// Plain OPTIONS requests are left to the router.
+			preflight := req.Method == http.MethodOptions && req.Header.Get(echo.HeaderAccessControlRequestMethod) != ""
+
+			routerAllowMethods := ""
+			if preflight {
+				if methods, ok := c.Get(echo.ContextKeyHeaderAllow).(string); ok && methods != "" {
+					routerAllowMethods = methods
+					res.Header().Set(echo.HeaderAllow, routerAllowMethods)
+				}
+			}
+
+			// No Origin provided
+			if origin == "" {
+				if !preflight {
+					return next(c)
+				}
+				return c.NoContent(http.StatusNoContent)
+			}
+
+			if config.AllowOriginFunc != nil {
+				allowed, err := config.AllowOriginFunc(origin)
+				if err != nil {
+					return err
+				}
+				if allowed {
+					allowOrigin = origin
+				}
+			} else {
+				// Check allowed origins
+				for _, o := range config.AllowOrigins {
+					if o == "*" && config.AllowCredentials && config.UnsafeWildcardOriginWithAllowCredentials {
+						allowOrigin = origin
+						break
+					}
+					if o == "*" || o == origin {
+						allowOrigin = o
+						break
+					}
+				}
+				// Check allowed origin patterns. Origins longer than the longest
+				// valid host name are not worth the cost of running the regexps.
+				if allowOrigin == "" && len(origin) <= (253+3+5) && strings.Contains(origin, "://") {
+					for _, re := range allowOriginPatterns {
+						if re.MatchString(origin) {
+							allowOrigin = origin
+							break
+						}
+					}
+				}
+			}
+
+			// Origin not allowed
+			if allowOrigin == "" {
+				if !preflight {
+					return next(c)
+				}
+				return c.NoContent(http.StatusNoContent)
+			}
+
+			res.Header().Set(echo.HeaderAccessControlAllowOrigin, allowOrigin)
+			if config.AllowCredentials {
+				res.Header().Set(echo.HeaderAccessControlAllowCredentials, "true")
+			}
+
+			// Simple request
+			if !preflight {
+				if exposeHeaders != "" {
+					res.Header().Set(echo.HeaderAccessControlExposeHeaders, exposeHeaders)
+				}
+				return next(c)
+			}
+
+			// Preflight request
+			res.Header().Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+			res.Header().Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+
+			if !hasCustomAllowMethods && routerAllowMethods != "" {
+				res.Header().Set(echo.HeaderAccessControlAllowMethods, routerAllowMethods)
+			} else {
+				res.Header().Set(echo.HeaderAccessControlAllowMethods, allowMethods)
+			}
+
+			if allowHeaders != "" {
+				res.Header().Set(echo.HeaderAccessControlAllowHeaders, allowHeaders)
+			} else {
+				h := req.Header.Get(echo.HeaderAccessControlRequestHeaders)
+				if h != "" {
+					res.Header().Set(echo.HeaderAccessControlAllowHeaders, h)
+				}
+			}
+			if config.MaxAge != 0 {
+				res.Header().Set(echo.HeaderAccessControlMaxAge, maxAge)
+			}
+			return c.NoContent(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	testCases := []struct {
+		name             string
+		givenMW          echo.MiddlewareFunc
+		whenMethod       string
+		whenHeaders      map[string]string
+		expectHeaders    map[string]string
+		notExpectHeaders []string
+		expectStatus     int
+	}{
+		{
+			name:        "ok, wildcard origin",
+			whenHeaders: map[string]string{echo.HeaderOrigin: "localhost"},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlAllowOrigin: "*",
+				echo.HeaderVary:                     echo.HeaderOrigin,
+			},
+		},
+		{
+			name:             "ok, wildcard AllowedOrigin with no Origin header in request",
+			notExpectHeaders: []string{echo.HeaderAccessControlAllowOrigin},
+		},
+		{
+			name: "ok, specific AllowOrigins and AllowCredentials",
+			givenMW: CORSWithConfig(CORSConfig{
+				AllowOrigins:     []string{"http://localhost", "http://localhost:8080"},
+				AllowCredentials: true,
+				ExposeHeaders:    []string{echo.HeaderXRequestID},
+			}),
+			whenHeaders: map[string]string{echo.HeaderOrigin: "http://localhost"},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlAllowOrigin:      "http://localhost",
+				echo.HeaderAccessControlAllowCredentials: "true",
+				echo.HeaderAccessControlExposeHeaders:    echo.HeaderXRequestID,
+			},
+		},
+		{
+			name: "ok, origin not allowed",
+			givenMW: CORSWithConfig(CORSConfig{
+				AllowOrigins: []string{"http://localhost"},
+			}),
+			whenHeaders:      map[string]string{echo.HeaderOrigin: "http://evil.com"},
+			notExpectHeaders: []string{echo.HeaderAccessControlAllowOrigin},
+		},
+		{
+			name: "ok, preflight request with custom config",
+			givenMW: CORSWithConfig(CORSConfig{
+				AllowOrigins:     []string{"http://localhost"},
+				AllowMethods:     []string{http.MethodGet, http.MethodPost},
+				AllowHeaders:     []string{"X-Custom"},
+				AllowCredentials: true,
+				MaxAge:           3600,
+			}),
+			whenMethod: http.MethodOptions,
+			whenHeaders: map[string]string{
+				echo.HeaderOrigin:                     "http://localhost",
+				echo.HeaderAccessControlRequestMethod: http.MethodPost,
+			},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlAllowOrigin:      "http://localhost",
+				echo.HeaderAccessControlAllowMethods:     "GET,POST",
+				echo.HeaderAccessControlAllowHeaders:     "X-Custom",
+				echo.HeaderAccessControlAllowCredentials: "true",
+				echo.HeaderAccessControlMaxAge:           "3600",
+			},
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name:       "ok, preflight request echoes requested headers",
+			whenMethod: http.MethodOptions,
+			whenHeaders: map[string]string{
+				echo.HeaderOrigin:                      "http://localhost",
+				echo.HeaderAccessControlRequestMethod:  http.MethodPost,
+				echo.HeaderAccessControlRequestHeaders: "X-Token, Content-Type",
+			},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlAllowOrigin:  "*",
+				echo.HeaderAccessControlAllowHeaders: "X-Token, Content-Type",
+			},
+			notExpectHeaders: []string{echo.HeaderAccessControlMaxAge},
+			expectStatus:     http.StatusNoContent,
+		},
+		{
+			name: "ok, preflight request with negative MaxAge",
+			givenMW: CORSWithConfig(CORSConfig{
+				MaxAge: -1,
+			}),
+			whenMethod: http.MethodOptions,
+			whenHeaders: map[string]string{
+				echo.HeaderOrigin:                     "http://localhost",
+				echo.HeaderAccessControlRequestMethod: http.MethodGet,
+			},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlMaxAge: "0",
+			},
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name: "ok, wildcard with credentials does not reflect origin by default",
+			givenMW: CORSWithConfig(CORSConfig{
+				AllowCredentials: true,
+			}),
+			whenHeaders: map[string]string{echo.HeaderOrigin: "http://localhost"},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlAllowOrigin: "*",
+			},
+		},
+		{
+			name: "ok, unsafe wildcard with credentials reflects origin",
+			givenMW: CORSWithConfig(CORSConfig{
+				AllowCredentials:                         true,
+				UnsafeWildcardOriginWithAllowCredentials: true,
+			}),
+			whenHeaders: map[string]string{echo.HeaderOrigin: "http://localhost"},
+			expectHeaders: map[string]string{
+				echo.HeaderAccessControlAllowOrigin: "http://localhost",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+
+			mw := CORS()
+			if tc.givenMW != nil {
+				mw = tc.givenMW
+			}
+			method := http.MethodGet
+			if tc.whenMethod != "" {
+				method = tc.whenMethod
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			for k, v := range tc.whenHeaders {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := mw(func(c echo.Context) error {
+				return nil
+			})(c)
+			assert.NoError(t, err)
+
+			for k, v := range tc.expectHeaders {
+				assert.Equal(t, v, rec.Header().Get(k), k)
+			}
+			for _, k := range tc.notExpectHeaders {
+				assert.Empty(t, rec.Header().Get(k), k)
+			}
+			if tc.expectStatus != 0 {
+				assert.Equal(t, tc.expectStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight_RouterAllowMethods(t *testing.T) {
+	e := echo.New()
+	e.Use(CORSWithConfig(CORSConfig{
+		AllowOrigins: []string{"http://localhost"},
+	}))
+	e.GET("/users", func(c echo.Context) error { return nil })
+	e.POST("/users", func(c echo.Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	req.Header.Set(echo.HeaderOrigin, "http://localhost")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "OPTIONS, GET, POST", rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+	assert.Equal(t, "OPTIONS, GET, POST", rec.Header().Get(echo.HeaderAllow))
+	assert.Equal(t, []string{
+		echo.HeaderOrigin,
+		echo.HeaderAccessControlRequestMethod,
+		echo.HeaderAccessControlRequestHeaders,
+	}, rec.Header()[echo.HeaderVary])
+}
+
+func TestCORS_AllowOriginPatterns(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		origin  string
+		expect  bool
+	}{
+		{pattern: "https://*.example.com", origin: "https://api.example.com", expect: true},
+		{pattern: "https://*.example.com", origin: "https://a.b.example.com", expect: true},
+		{pattern: "https://*.example.com", origin: "https://example.com", expect: false},
+		{pattern: "https://*.example.com", origin: "http://api.example.com", expect: false},
+		{pattern: "https://*.example.com", origin: "https://evil.com/.example.com", expect: false},
+		{pattern: "https://*.example.com", origin: "https://api.example.com.evil.com", expect: false},
+		{pattern: "http://localhost:300?", origin: "http://localhost:3001", expect: true},
+	}
+
+	e := echo.New()
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderOrigin, tc.origin)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mw := CORSWithConfig(CORSConfig{AllowOrigins: []string{tc.pattern}})
+			assert.NoError(t, mw(func(c echo.Context) error { return nil })(c))
+
+			if tc.expect {
+				assert.Equal(t, tc.origin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			} else {
+				assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			}
+		})
+	}
+}
+
+func TestCORS_AllowOriginFunc(t *testing.T) {
+	e := echo.New()
+	mw := CORSWithConfig(CORSConfig{
+		AllowOriginFunc: func(origin string) (bool, error) {
+			if origin == "http://broken" {
+				return false, errors.New("broken")
+			}
+			return origin == "http://allowed", nil
+		},
+	})
+
+	for origin, expect := range map[string]string{"http://allowed": "http://allowed", "http://denied": ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, mw(func(c echo.Context) error { return nil })(c))
+		assert.Equal(t, expect, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderOrigin, "http://broken")
+	c := e.NewContext(req, httptest.NewRecorder())
+	assert.EqualError(t, mw(func(c echo.Context) error { return nil })(c), "broken")
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRF(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	csrf := CSRFWithConfig(CSRFConfig{
+		TokenLength: 16,
+	})
+	h := csrf(func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+
+	// Generate CSRF token
+	assert.NoError(t, h(c))
+	assert.Contains(t, rec.Header().Get(echo.HeaderSetCookie), "_csrf")
+	assert.Equal(t, echo.HeaderCookie, rec.Header().Get(echo.HeaderVary))
+
+	// Without CSRF cookie
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	err := h(c)
+	if assert.IsType(t, &echo.HTTPError{}, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	}
+
+	// Invalid CSRF token
+	req.Header.Set(echo.HeaderCookie, "_csrf=token")
+	req.Header.Set(echo.HeaderXCSRFToken, "invalid")
+	err = h(c)
+	assert.Equal(t, ErrCSRFInvalid, err)
+
+	// Valid CSRF token
+	token := randomString(16)
+	req.Header.Set(echo.HeaderCookie, "_csrf="+token)
+	req.Header.Set(echo.HeaderXCSRFToken, token)
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
+
+func TestCSRF_TokenLookup(t *testing.T) {
+	token := randomString(32)
+	testCases := []struct {
+		name        string
+		lookup      string
+		whenRequest func() *http.Request
+	}{
+		{
+			name:   "form",
+			lookup: "form:_csrf",
+			whenRequest: func() *http.Request {
+				f := make(url.Values)
+				f.Set("_csrf", token)
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(f.Encode()))
+				req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationForm)
+				return req
+			},
+		},
+		{
+			name:   "query",
+			lookup: "query:csrf",
+			whenRequest: func() *http.Request {
+				return httptest.NewRequest(http.MethodPut, "/?csrf="+token, nil)
+			},
+		},
+		{
+			name:   "second of multiple sources",
+			lookup: "header:X-CSRF-Token,query:csrf",
+			whenRequest: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/?csrf="+token, nil)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := tc.whenRequest()
+			req.Header.Set(echo.HeaderCookie, "_csrf="+token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var stored interface{}
+			h := CSRFWithConfig(CSRFConfig{TokenLookup: tc.lookup})(func(c echo.Context) error {
+				stored = c.Get("csrf")
+				return c.NoContent(http.StatusOK)
+			})
+
+			assert.NoError(t, h(c))
+			assert.Equal(t, token, stored)
+		})
+	}
+}
+
+func TestCSRFWithConfig_Cookie(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := CSRFWithConfig(CSRFConfig{
+		CookieName:     "xsrf",
+		CookieDomain:   "example.com",
+		CookiePath:     "/app",
+		CookieHTTPOnly: true,
+		CookieSameSite: http.SameSiteNoneMode,
+	})(func(c echo.Context) error {
+		return nil
+	})
+
+	assert.NoError(t, h(c))
+	cookie := rec.Header().Get(echo.HeaderSetCookie)
+	assert.Contains(t, cookie, "xsrf=")
+	assert.Contains(t, cookie, "Domain=example.com")
+	assert.Contains(t, cookie, "Path=/app")
+	assert.Contains(t, cookie, "HttpOnly")
+	assert.Contains(t, cookie, "SameSite=None")
+	assert.Contains(t, cookie, "Secure")
+}
+
+func TestCSRFWithConfig_SkipperAndErrorHandler(t *testing.T) {
+	e := echo.New()
+	mw := CSRFWithConfig(CSRFConfig{
+		Skipper: func(c echo.Context) bool {
+			return c.QueryParam("skip") != ""
+		},
+		ErrorHandler: func(err error, c echo.Context) error {
+			return echo.NewHTTPError(http.StatusTeapot, "custom")
+		},
+	})
+	h := mw(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/?skip=1", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, h(e.NewContext(req, rec)))
+	assert.Empty(t, rec.Header().Get(echo.HeaderSetCookie))
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	err := h(e.NewContext(req, httptest.NewRecorder()))
+	assert.Equal(t, http.StatusTeapot, err.(*echo.HTTPError).Code)
+}
+
+func TestRandomString(t *testing.T) {
+	s := randomString(64)
+	assert.Len(t, s, 64)
+	assert.Equal(t, -1, strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune(randomStringCharset, r)
+	}))
+	assert.NotEqual(t, s, randomString(64))
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// DecompressConfig defines the config for Decompress middleware.
+	DecompressConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// MaxDecompressedSize is the maximum size in bytes a request body may
+		// inflate to. Reading beyond it fails with
+		// `echo.ErrStatusRequestEntityTooLarge`, which guards against zip bombs.
+		// Optional. Default value 32MB.
+		MaxDecompressedSize int64
+	}
+)
+
+var (
+	// DefaultDecompressConfig defines the config for decompress middleware
+	DefaultDecompressConfig = DecompressConfig{
+		Skipper:             DefaultSkipper,
+		MaxDecompressedSize: 32 << 20, // 32 MB
+	}
+)
+
+// Decompress decompresses request body based if content encoding type is set to
+// "gzip" or "deflate" with default config.
+func Decompress() echo.MiddlewareFunc {
+	return DecompressWithConfig(DefaultDecompressConfig)
+}
+
+// DecompressWithConfig decompresses request body based if content encoding type
+// is set to "gzip" or "deflate" with config.
+// See: `Decompress()`.
+func DecompressWithConfig(config DecompressConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultDecompressConfig.Skipper
+	}
+	if config.MaxDecompressedSize == 0 {
+		config.MaxDecompressedSize = DefaultDecompressConfig.MaxDecompressedSize
+	}
+
+	// gzip readers can be reset without input, zlib readers need a valid header
+	// to be created, so the zlib pool starts empty.
+	gzipPool := &sync.Pool{
+		New: func() interface{} {
+			return new(gzip.Reader)
+		},
+	}
+	zlibPool := &sync.Pool{}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			encoding := strings.ToLower(strings.TrimSpace(req.Header.Get(echo.HeaderContentEncoding)))
+
+			var pool *sync.Pool
+			var r io.ReadCloser
+			switch encoding {
+			case "gzip", "x-gzip":
+				pool = gzipPool
+				gr := gzipPool.Get().(*gzip.Reader)
+				if err := gr.Reset(req.Body); err != nil {
+					gzipPool.Put(gr)
+					if err == io.EOF { // empty body
+						return next(c)
+					}
+					return echo.NewHTTPError(http.StatusBadRequest, "invalid gzip request body").SetInternal(err)
+				}
+				r = gr
+			case "deflate":
+				pool = zlibPool
+				var err error
+				if zr, ok := zlibPool.Get().(io.ReadCloser); ok {
+					err = zr.(zlib.Resetter).Reset(req.Body, nil)
+					r = zr
+				} else {
+					r, err = zlib.NewReader(req.Body)
+				}
+				if err != nil {
+					if err == io.EOF { // empty body
+						return next(c)
+					}
+					return echo.NewHTTPError(http.StatusBadRequest, "invalid deflate request body").SetInternal(err)
+				}
+			default:
+				return next(c)
+			}
+			// Handlers may still read the body after returning, e.g. when
+			// abandoned by the Timeout middleware, so the reader is only
+			// pooled once no read is in progress.
+			pr := &pooledReader{reader: r, pool: pool}
+			defer pr.Close()
+
+			// The body no longer matches the headers describing the compressed payload.
+			req.Header.Del(echo.HeaderContentEncoding)
+			req.Header.Del(echo.HeaderContentLength)
+			req.ContentLength = -1
+			req.Body = &readCloser{
+				Reader: &limitedReader{reader: pr, limit: config.MaxDecompressedSize},
+				Closer: req.Body,
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// errDecompressReaderClosed is returned when the body is read after the
+// middleware returned.
+var errDecompressReaderClosed = errors.New("decompress: read after request completed")
+
+// pooledReader returns reader to pool once closed and no read is in progress.
+// Reads after Close fail, so a reader is never shared by two requests.
+type pooledReader struct {
+	mu      sync.Mutex
+	reader  io.ReadCloser
+	pool    *sync.Pool
+	reading bool
+	closed  bool
+}
+
+func (r *pooledReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return 0, errDecompressReaderClosed
+	}
+	r.reading = true
+	r.mu.Unlock()
+
+	n, err := r.reader.Read(b)
+
+	r.mu.Lock()
+	r.reading = false
+	if r.closed {
+		r.release()
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+func (r *pooledReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		if !r.reading {
+			r.release()
+		}
+	}
+	return nil
+}
+
+func (r *pooledReader) release() {
+	r.reader.Close()
+	r.pool.Put(r.reader)
+	r.reader = nil
+}
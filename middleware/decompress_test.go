@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipString(t *testing.T, body string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(body))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	e := echo.New()
+	body := `{"name": "echo"}`
+
+	var zbuf bytes.Buffer
+	zw := zlib.NewWriter(&zbuf)
+	zw.Write([]byte(body))
+	zw.Close()
+
+	testCases := []struct {
+		name     string
+		encoding string
+		payload  []byte
+	}{
+		{name: "gzip", encoding: "gzip", payload: gzipString(t, body)},
+		{name: "x-gzip", encoding: "x-gzip", payload: gzipString(t, body)},
+		{name: "deflate", encoding: "deflate", payload: zbuf.Bytes()},
+		{name: "identity", encoding: "", payload: []byte(body)},
+	}
+
+	h := Decompress()(func(c echo.Context) error {
+		b, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(b))
+	})
+
+	// run twice to go through pooled readers
+	for i := 0; i < 2; i++ {
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.payload))
+				if tc.encoding != "" {
+					req.Header.Set(echo.HeaderContentEncoding, tc.encoding)
+				}
+				rec := httptest.NewRecorder()
+				c := e.NewContext(req, rec)
+
+				assert.NoError(t, h(c))
+				assert.Equal(t, body, rec.Body.String())
+				assert.Empty(t, req.Header.Get(echo.HeaderContentEncoding))
+			})
+		}
+	}
+}
+
+func TestDecompress_EmptyAndInvalidBody(t *testing.T) {
+	e := echo.New()
+	h := Decompress()(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	req.Header.Set(echo.HeaderContentEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	assert.NoError(t, h(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("this is not a gzip payload"))
+	req.Header.Set(echo.HeaderContentEncoding, "gzip")
+	err := h(e.NewContext(req, httptest.NewRecorder()))
+	if assert.IsType(t, &echo.HTTPError{}, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		assert.Equal(t, gzip.ErrHeader, err.(*echo.HTTPError).Internal)
+	}
+}
+
+func TestDecompressWithConfig_MaxDecompressedSize(t *testing.T) {
+	e := echo.New()
+	// 1MB of zeroes compresses to about 1KB
+	payload := gzipString(t, strings.Repeat("\x00", 1<<20))
+
+	h := DecompressWithConfig(DecompressConfig{MaxDecompressedSize: 1024})(func(c echo.Context) error {
+		_, err := io.ReadAll(c.Request().Body)
+		return err
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentEncoding, "gzip")
+	err := h(e.NewContext(req, httptest.NewRecorder()))
+	assert.Equal(t, echo.ErrStatusRequestEntityTooLarge, err)
+}
+
+type blockingReadCloser struct {
+	release chan struct{}
+	closed  int32
+}
+
+func (r *blockingReadCloser) Read(b []byte) (int, error) {
+	<-r.release
+	return 0, io.EOF
+}
+
+func (r *blockingReadCloser) Close() error {
+	atomic.AddInt32(&r.closed, 1)
+	return nil
+}
+
+func TestDecompress_pooledReader(t *testing.T) {
+	r := &blockingReadCloser{release: make(chan struct{})}
+	pr := &pooledReader{reader: r, pool: &sync.Pool{}}
+
+	done := make(chan struct{})
+	go func() {
+		pr.Read(make([]byte, 1))
+		close(done)
+	}()
+	for reading := false; !reading; {
+		pr.mu.Lock()
+		reading = pr.reading
+		pr.mu.Unlock()
+	}
+
+	// the reader is not pooled while a read is in progress
+	assert.NoError(t, pr.Close())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&r.closed))
+
+	close(r.release)
+	<-done
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r.closed))
+
+	_, err := pr.Read(make([]byte, 1))
+	assert.Equal(t, errDecompressReaderClosed, err)
+	assert.NoError(t, pr.Close())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r.closed))
+}
+
+func TestDecompress_readAfterReturn(t *testing.T) {
+	e := echo.New()
+	var body io.Reader
+	h := Decompress()(func(c echo.Context) error {
+		body = c.Request().Body
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(gzipString(t, "test")))
+	req.Header.Set(echo.HeaderContentEncoding, "gzip")
+	assert.NoError(t, h(e.NewContext(req, httptest.NewRecorder())))
+
+	_, err := io.ReadAll(body)
+	assert.Equal(t, errDecompressReaderClosed, err)
+}
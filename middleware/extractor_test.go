@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExtractors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		givenLookup string
+		whenRequest func(req *http.Request)
+		expect      []string
+		expectErr   string
+	}{
+		{
+			name:        "ok, header",
+			givenLookup: "header:X-Token",
+			whenRequest: func(req *http.Request) { req.Header.Set("x-token", "abc") },
+			expect:      []string{"abc"},
+		},
+		{
+			name:        "ok, header with cut prefix",
+			givenLookup: "header:Authorization:Bearer ",
+			whenRequest: func(req *http.Request) { req.Header.Set(echo.HeaderAuthorization, "bearer abc") },
+			expect:      []string{"abc"},
+		},
+		{
+			name:        "nok, header with wrong prefix",
+			givenLookup: "header:Authorization:Bearer ",
+			whenRequest: func(req *http.Request) { req.Header.Set(echo.HeaderAuthorization, "Basic abc") },
+			expectErr:   "invalid value in request header",
+		},
+		{
+			name:        "ok, cookie",
+			givenLookup: "cookie:session",
+			whenRequest: func(req *http.Request) { req.Header.Set(echo.HeaderCookie, "other=1; session=abc") },
+			expect:      []string{"abc"},
+		},
+		{
+			name:        "nok, query missing",
+			givenLookup: "query:token",
+			whenRequest: func(req *http.Request) {},
+			expectErr:   "missing value in the query string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			tc.whenRequest(req)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			extractors, err := CreateExtractors(tc.givenLookup)
+			assert.NoError(t, err)
+			assert.Len(t, extractors, 1)
+
+			values, err := extractors[0](c)
+			if tc.expectErr != "" {
+				assert.EqualError(t, err, tc.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expect, values)
+		})
+	}
+}
+
+func TestCreateExtractors_invalidLookup(t *testing.T) {
+	_, err := CreateExtractors("header")
+	assert.EqualError(t, err, "extractor source for lookup could not be split into needed parts: header")
+
+	_, err = CreateExtractors("body:token")
+	assert.EqualError(t, err, "extractor source for lookup is not supported: body:token")
+}
@@ -0,0 +1,557 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// JWTConfig defines the config for JWT middleware.
+	JWTConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// BeforeFunc defines a function which is executed just before the middleware.
+		BeforeFunc BeforeFunc
+
+		// SuccessHandler defines a function which is executed for a valid token.
+		SuccessHandler func(c echo.Context)
+
+		// ErrorHandler defines a function which is executed when the token is
+		// missing or invalid. It may be used to define a custom error.
+		//
+		// Note: when error handler swallows the error (returns nil) and
+		// ContinueOnIgnoredError is set the middleware continues the handler
+		// chain, which is useful for routes with optional authentication.
+		ErrorHandler func(c echo.Context, err error) error
+
+		// ContinueOnIgnoredError allows the next middleware/handler to be called when ErrorHandler decides to
+		// ignore the error (by returning `nil`).
+		ContinueOnIgnoredError bool
+
+		// SigningKey is the key to verify tokens without a key ID ("kid" header).
+		// The key type selects the algorithm: []byte for HS256, *rsa.PublicKey
+		// for RS256 and *ecdsa.PublicKey (P-256) for ES256.
+		// Required, unless SigningKeys is set.
+		SigningKey interface{}
+
+		// SigningKeys maps key IDs to keys, see SigningKey for the supported
+		// types. Tokens with a "kid" header are verified with the matching key.
+		// `LoadJWKSFile()` reads them from a JSON Web Key Set.
+		// Optional.
+		SigningKeys map[string]interface{}
+
+		// ContextKey is the key the claims are stored under in the context.
+		// Optional. Default value "user".
+		ContextKey string
+
+		// NewClaimsFunc returns the value the token claims are decoded into,
+		// usually a pointer to a struct embedding `RegisteredClaims`.
+		// Optional. Default value returns a new `*RegisteredClaims`.
+		NewClaimsFunc func(c echo.Context) JWTClaims
+
+		// TokenLookup is a string in the form of "<source>:<name>" or "<source>:<name>,<source>:<name>" that is used
+		// to extract token from the request.
+		// Optional. Default value "header:Authorization".
+		// Possible values:
+		// - "header:<name>" or "header:<name>:<cut-prefix>", see `CreateExtractors()`
+		// - "query:<name>"
+		// - "cookie:<name>"
+		// - "form:<name>"
+		TokenLookup string
+
+		// AuthScheme to be used in the Authorization header.
+		// Optional. Default value "Bearer".
+		AuthScheme string
+
+		// Realm is sent in the `WWW-Authenticate` challenge.
+		// Optional. Default value "Restricted".
+		Realm string
+
+		// Issuer is the expected "iss" claim. Not checked when empty.
+		Issuer string
+
+		// Audience is a value expected in the "aud" claim. Not checked when empty.
+		Audience string
+
+		// ClockSkew is the leeway when checking "exp" and "nbf" to allow for
+		// clocks of the issuer and this server to drift apart.
+		// Optional. Default value 0.
+		ClockSkew time.Duration
+
+		// TimeFunc returns the current time to validate the token against.
+		// Optional. Default value time.Now.
+		TimeFunc func() time.Time
+	}
+
+	// JWTClaims is implemented by claim types, usually by embedding
+	// `RegisteredClaims` in a struct with the application specific claims.
+	JWTClaims interface {
+		Registered() *RegisteredClaims
+	}
+
+	// RegisteredClaims are the claims registered in RFC 7519 section 4.1.
+	RegisteredClaims struct {
+		Issuer    string       `json:"iss,omitempty"`
+		Subject   string       `json:"sub,omitempty"`
+		Audience  ClaimStrings `json:"aud,omitempty"`
+		ExpiresAt *NumericDate `json:"exp,omitempty"`
+		NotBefore *NumericDate `json:"nbf,omitempty"`
+		IssuedAt  *NumericDate `json:"iat,omitempty"`
+		ID        string       `json:"jti,omitempty"`
+	}
+
+	// ClaimStrings is a claim which may be a single string or an array of
+	// strings, like "aud".
+	ClaimStrings []string
+
+	// NumericDate is a JSON number of seconds since the epoch.
+	NumericDate struct {
+		time.Time
+	}
+
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Typ string `json:"typ,omitempty"`
+		Kid string `json:"kid,omitempty"`
+	}
+
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+		K   string `json:"k"`
+	}
+)
+
+// Errors returned as the internal error when a token is rejected.
+var (
+	ErrJWTMissing          = errors.New("missing or malformed jwt")
+	ErrJWTMalformed        = errors.New("token is malformed")
+	ErrJWTUnverifiable     = errors.New("token is unverifiable")
+	ErrJWTSignatureInvalid = errors.New("token signature is invalid")
+	ErrJWTExpired          = errors.New("token is expired")
+	ErrJWTNotValidYet      = errors.New("token is not valid yet")
+	ErrJWTInvalidIssuer    = errors.New("token has invalid issuer")
+	ErrJWTInvalidAudience  = errors.New("token has invalid audience")
+)
+
+var (
+	// DefaultJWTConfig is the default JWT auth middleware config.
+	DefaultJWTConfig = JWTConfig{
+		Skipper:     DefaultSkipper,
+		ContextKey:  "user",
+		TokenLookup: "header:" + echo.HeaderAuthorization,
+		AuthScheme:  "Bearer",
+		Realm:       defaultRealm,
+		NewClaimsFunc: func(c echo.Context) JWTClaims {
+			return new(RegisteredClaims)
+		},
+		TimeFunc: time.Now,
+	}
+)
+
+// JWT returns a JSON Web Token (JWT) auth middleware.
+//
+// For valid token, it stores the claims in the context and calls next handler.
+// For missing or invalid token, it returns "401 - Unauthorized" error along
+// with a `WWW-Authenticate` challenge.
+//
+// See: https://jwt.io/introduction
+func JWT(key interface{}) echo.MiddlewareFunc {
+	c := DefaultJWTConfig
+	c.SigningKey = key
+	return JWTWithConfig(c)
+}
+
+// JWTWithConfig returns a JWT auth middleware with config.
+// See: `JWT()`.
+func JWTWithConfig(config JWTConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultJWTConfig.Skipper
+	}
+	if config.SigningKey == nil && len(config.SigningKeys) == 0 {
+		panic("echo: jwt middleware requires signing key")
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultJWTConfig.ContextKey
+	}
+	if config.TokenLookup == "" {
+		config.TokenLookup = DefaultJWTConfig.TokenLookup
+	}
+	if config.AuthScheme == "" {
+		config.AuthScheme = DefaultJWTConfig.AuthScheme
+	}
+	if config.Realm == "" {
+		config.Realm = DefaultJWTConfig.Realm
+	}
+	if config.NewClaimsFunc == nil {
+		config.NewClaimsFunc = DefaultJWTConfig.NewClaimsFunc
+	}
+	if config.TimeFunc == nil {
+		config.TimeFunc = DefaultJWTConfig.TimeFunc
+	}
+	if config.SigningKey != nil {
+		if err := checkJWTKey(config.SigningKey); err != nil {
+			panic(err)
+		}
+	}
+	for kid, key := range config.SigningKeys {
+		if err := checkJWTKey(key); err != nil {
+			panic(fmt.Errorf("%w, kid=%s", err, kid))
+		}
+	}
+
+	extractors, err := createExtractors(config.TokenLookup, config.AuthScheme)
+	if err != nil {
+		panic(err)
+	}
+	challenge := config.AuthScheme + " realm=" + strconv.Quote(config.Realm)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			if config.BeforeFunc != nil {
+				config.BeforeFunc(c)
+			}
+
+			var lastExtractorErr error
+			var lastTokenErr error
+			for _, extractor := range extractors {
+				auths, err := extractor(c)
+				if err != nil {
+					lastExtractorErr = ErrJWTMissing
+					continue
+				}
+				for _, auth := range auths {
+					claims := config.NewClaimsFunc(c)
+					if err := config.parseToken(auth, claims); err != nil {
+						lastTokenErr = err
+						continue
+					}
+					// Store user information from token into context.
+					c.Set(config.ContextKey, claims)
+					if config.SuccessHandler != nil {
+						config.SuccessHandler(c)
+					}
+					return next(c)
+				}
+			}
+
+			// prioritize token errors over extracting errors
+			err := lastTokenErr
+			if err == nil {
+				err = lastExtractorErr
+			}
+			if config.ErrorHandler != nil {
+				tmpErr := config.ErrorHandler(c, err)
+				if config.ContinueOnIgnoredError && tmpErr == nil {
+					return next(c)
+				}
+				return tmpErr
+			}
+
+			// RFC 6750 section 3.1: no error code when the request lacks a token.
+			if lastTokenErr == nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
+				return echo.ErrUnauthorized
+			}
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge+
+				`, error="invalid_token", error_description=`+strconv.Quote(lastTokenErr.Error()))
+			return &echo.HTTPError{
+				Code:     echo.ErrUnauthorized.Code,
+				Message:  echo.ErrUnauthorized.Message,
+				Internal: lastTokenErr,
+			}
+		}
+	}
+}
+
+// parseToken verifies the signature of the compact serialized token and
+// decodes its claims into claims, which are then validated.
+func (config *JWTConfig) parseToken(token string, claims JWTClaims) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrJWTMalformed
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return err
+	}
+	key := config.SigningKey
+	if header.Kid != "" && config.SigningKeys != nil {
+		k, ok := config.SigningKeys[header.Kid]
+		if !ok {
+			return fmt.Errorf("%w: unknown kid %q", ErrJWTUnverifiable, header.Kid)
+		}
+		key = k
+	}
+	if key == nil {
+		return fmt.Errorf("%w: no key for token", ErrJWTUnverifiable)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return err
+	}
+
+	if err := decodeJWTSegment(parts[1], claims); err != nil {
+		return err
+	}
+	return config.validateClaims(claims.Registered())
+}
+
+func (config *JWTConfig) validateClaims(rc *RegisteredClaims) error {
+	now := config.TimeFunc()
+	if rc.ExpiresAt != nil && now.After(rc.ExpiresAt.Add(config.ClockSkew)) {
+		return ErrJWTExpired
+	}
+	if rc.NotBefore != nil && now.Add(config.ClockSkew).Before(rc.NotBefore.Time) {
+		return ErrJWTNotValidYet
+	}
+	if config.Issuer != "" && rc.Issuer != config.Issuer {
+		return ErrJWTInvalidIssuer
+	}
+	if config.Audience != "" {
+		found := false
+		for _, aud := range rc.Audience {
+			if aud == config.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrJWTInvalidAudience
+		}
+	}
+	return nil
+}
+
+func decodeJWTSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrJWTMalformed, err)
+	}
+	return nil
+}
+
+// verifyJWTSignature checks signature of signed. The algorithm in the token
+// header has to match the type of key so a token cannot pick a weaker
+// verification, e.g. HS256 with an RSA public key as secret.
+func verifyJWTSignature(alg string, key interface{}, signed string, signature []byte) error {
+	hash := sha256.Sum256([]byte(signed))
+	switch k := key.(type) {
+	case []byte:
+		if alg != "HS256" {
+			break
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrJWTSignatureInvalid
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature); err != nil {
+			return ErrJWTSignatureInvalid
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			break
+		}
+		if len(signature) != 64 {
+			return ErrJWTSignatureInvalid
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, hash[:], r, s) {
+			return ErrJWTSignatureInvalid
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unexpected signing method %q", ErrJWTUnverifiable, alg)
+}
+
+func checkJWTKey(key interface{}) error {
+	switch k := key.(type) {
+	case []byte, *rsa.PublicKey:
+		return nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return nil
+		}
+	}
+	return fmt.Errorf("echo: jwt middleware does not support signing key of type %T", key)
+}
+
+// Registered returns the registered claims, see JWTClaims.
+func (c *RegisteredClaims) Registered() *RegisteredClaims {
+	return c
+}
+
+// UnmarshalJSON accepts a single string as well as an array of strings.
+func (s *ClaimStrings) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case string:
+		*s = ClaimStrings{value}
+	case []interface{}:
+		res := make(ClaimStrings, 0, len(value))
+		for _, vv := range value {
+			str, ok := vv.(string)
+			if !ok {
+				return fmt.Errorf("invalid claim string %v", vv)
+			}
+			res = append(res, str)
+		}
+		*s = res
+	case nil:
+		*s = nil
+	default:
+		return fmt.Errorf("invalid claim strings %v", v)
+	}
+	return nil
+}
+
+// UnmarshalJSON reads whole or fractional seconds since the epoch.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	f, err := strconv.ParseFloat(string(bytes.TrimSpace(data)), 64)
+	if err != nil {
+		return fmt.Errorf("invalid numeric date %s", data)
+	}
+	sec, frac := int64(f), f-float64(int64(f))
+	d.Time = time.Unix(sec, int64(frac*1e9))
+	return nil
+}
+
+// MarshalJSON writes the date as whole seconds since the epoch.
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(d.Unix(), 10)), nil
+}
+
+// LoadJWKSFile reads a JSON Web Key Set (RFC 7517) from a local file and
+// returns its keys by key ID, ready for `JWTConfig.SigningKeys`.
+func LoadJWKSFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set. RSA, EC P-256 and symmetric ("oct")
+// keys are supported, keys meant for encryption are skipped.
+func ParseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("echo: invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Kid == "" {
+			return nil, errors.New("echo: invalid jwks: key without kid")
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("echo: invalid jwks key %s: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.Alg != "" && k.Alg != "RS256" {
+			return nil, fmt.Errorf("unsupported alg %s", k.Alg)
+		}
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" || (k.Alg != "" && k.Alg != "ES256") {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "oct":
+		if k.Alg != "" && k.Alg != "HS256" {
+			return nil, fmt.Errorf("unsupported alg %s", k.Alg)
+		}
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
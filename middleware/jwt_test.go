@@ -0,0 +1,383 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+type jwtCustomClaims struct {
+	RegisteredClaims
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+// signJWT creates a compact serialized token for tests.
+func signJWT(t *testing.T, alg, kid string, key interface{}, claims interface{}) string {
+	header, _ := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT", Kid: kid})
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hash[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, hash[:])
+		assert.NoError(t, err)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func numericDate(t time.Time) *NumericDate {
+	return &NumericDate{t}
+}
+
+func TestJWT(t *testing.T) {
+	e := echo.New()
+	secret := []byte("secret")
+	token := signJWT(t, "HS256", "", secret, map[string]interface{}{"sub": "1234567890"})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := JWT(secret)(func(c echo.Context) error {
+		claims := c.Get("user").(*RegisteredClaims)
+		return c.String(http.StatusOK, claims.Subject)
+	})(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1234567890", rec.Body.String())
+}
+
+func TestJWTWithConfig(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	valid := jwtCustomClaims{
+		RegisteredClaims: RegisteredClaims{
+			Issuer:    "issuer",
+			Audience:  ClaimStrings{"api"},
+			ExpiresAt: numericDate(now.Add(time.Minute)),
+			NotBefore: numericDate(now.Add(-time.Minute)),
+		},
+		Name:  "Jon Snow",
+		Admin: true,
+	}
+	expired := valid
+	expired.ExpiresAt = numericDate(now.Add(-10 * time.Second))
+	notYet := valid
+	notYet.NotBefore = numericDate(now.Add(10 * time.Second))
+
+	keys := map[string]interface{}{
+		"hs": secret,
+		"rs": &rsaKey.PublicKey,
+		"es": &ecKey.PublicKey,
+	}
+
+	testCases := []struct {
+		name            string
+		givenAuth       string
+		givenQuery      string
+		givenCookie     string
+		whenConfig      func(config *JWTConfig)
+		expectName      string
+		expectErr       error
+		expectChallenge string
+	}{
+		{
+			name:       "ok, HS256 by kid",
+			givenAuth:  "Bearer " + signJWT(t, "HS256", "hs", secret, valid),
+			expectName: "Jon Snow",
+		},
+		{
+			name:       "ok, RS256 by kid",
+			givenAuth:  "Bearer " + signJWT(t, "RS256", "rs", rsaKey, valid),
+			expectName: "Jon Snow",
+		},
+		{
+			name:       "ok, ES256 by kid",
+			givenAuth:  "Bearer " + signJWT(t, "ES256", "es", ecKey, valid),
+			expectName: "Jon Snow",
+		},
+		{
+			name:       "ok, without kid uses SigningKey",
+			givenAuth:  "Bearer " + signJWT(t, "RS256", "", rsaKey, valid),
+			whenConfig: func(config *JWTConfig) { config.SigningKey = &rsaKey.PublicKey },
+			expectName: "Jon Snow",
+		},
+		{
+			name:        "ok, from cookie",
+			givenCookie: signJWT(t, "HS256", "hs", secret, valid),
+			whenConfig:  func(config *JWTConfig) { config.TokenLookup = "query:jwt,cookie:jwt" },
+			expectName:  "Jon Snow",
+		},
+		{
+			name:       "ok, from query",
+			givenQuery: signJWT(t, "HS256", "hs", secret, valid),
+			whenConfig: func(config *JWTConfig) { config.TokenLookup = "query:jwt" },
+			expectName: "Jon Snow",
+		},
+		{
+			name:       "ok, expired within clock skew",
+			givenAuth:  "Bearer " + signJWT(t, "HS256", "hs", secret, expired),
+			whenConfig: func(config *JWTConfig) { config.ClockSkew = 30 * time.Second },
+			expectName: "Jon Snow",
+		},
+		{
+			name:            "nok, missing token",
+			expectErr:       echo.ErrUnauthorized,
+			expectChallenge: `Bearer realm="Restricted"`,
+		},
+		{
+			name:            "nok, expired",
+			givenAuth:       "Bearer " + signJWT(t, "HS256", "hs", secret, expired),
+			expectErr:       ErrJWTExpired,
+			expectChallenge: `Bearer realm="Restricted", error="invalid_token", error_description="token is expired"`,
+		},
+		{
+			name:      "nok, not valid yet",
+			givenAuth: "Bearer " + signJWT(t, "HS256", "hs", secret, notYet),
+			expectErr: ErrJWTNotValidYet,
+		},
+		{
+			name:       "nok, wrong issuer",
+			givenAuth:  "Bearer " + signJWT(t, "HS256", "hs", secret, valid),
+			whenConfig: func(config *JWTConfig) { config.Issuer = "other" },
+			expectErr:  ErrJWTInvalidIssuer,
+		},
+		{
+			name:       "nok, wrong audience",
+			givenAuth:  "Bearer " + signJWT(t, "HS256", "hs", secret, valid),
+			whenConfig: func(config *JWTConfig) { config.Audience = "other" },
+			expectErr:  ErrJWTInvalidAudience,
+		},
+		{
+			name:      "nok, bad signature",
+			givenAuth: "Bearer " + signJWT(t, "HS256", "hs", []byte("wrong"), valid),
+			expectErr: ErrJWTSignatureInvalid,
+		},
+		{
+			name:      "nok, algorithm does not match key",
+			givenAuth: "Bearer " + signJWT(t, "HS256", "rs", secret, valid),
+			expectErr: ErrJWTUnverifiable,
+		},
+		{
+			name:      "nok, alg none",
+			givenAuth: "Bearer " + signJWT(t, "none", "hs", nil, valid),
+			expectErr: ErrJWTUnverifiable,
+		},
+		{
+			name:      "nok, unknown kid",
+			givenAuth: "Bearer " + signJWT(t, "HS256", "unknown", secret, valid),
+			expectErr: ErrJWTUnverifiable,
+		},
+		{
+			name:      "nok, malformed",
+			givenAuth: "Bearer abc.def",
+			expectErr: ErrJWTMalformed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.givenAuth != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.givenAuth)
+			}
+			if tc.givenQuery != "" {
+				req.URL.RawQuery = "jwt=" + tc.givenQuery
+			}
+			if tc.givenCookie != "" {
+				req.AddCookie(&http.Cookie{Name: "jwt", Value: tc.givenCookie})
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			config := JWTConfig{
+				SigningKeys: keys,
+				Issuer:      "issuer",
+				Audience:    "api",
+				NewClaimsFunc: func(c echo.Context) JWTClaims {
+					return new(jwtCustomClaims)
+				},
+				TimeFunc: func() time.Time { return now },
+			}
+			if tc.whenConfig != nil {
+				tc.whenConfig(&config)
+			}
+
+			err := JWTWithConfig(config)(func(c echo.Context) error {
+				claims := c.Get("user").(*jwtCustomClaims)
+				assert.True(t, claims.Admin)
+				return c.String(http.StatusOK, claims.Name)
+			})(c)
+
+			if tc.expectErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectName, rec.Body.String())
+				return
+			}
+			var he *echo.HTTPError
+			if assert.ErrorAs(t, err, &he) {
+				assert.Equal(t, http.StatusUnauthorized, he.Code)
+			}
+			if tc.expectErr != echo.ErrUnauthorized {
+				assert.ErrorIs(t, err, tc.expectErr)
+			} else {
+				assert.Equal(t, echo.ErrUnauthorized, err)
+			}
+			if tc.expectChallenge != "" {
+				assert.Equal(t, tc.expectChallenge, rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+		})
+	}
+}
+
+func TestJWTWithConfig_errorHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var handlerErr error
+	mw := JWTWithConfig(JWTConfig{
+		SigningKey:             []byte("secret"),
+		ContinueOnIgnoredError: true,
+		ErrorHandler: func(c echo.Context, err error) error {
+			handlerErr = err
+			c.Set("user", "public")
+			return nil
+		},
+	})
+	err := mw(func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get("user").(string))
+	})(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ErrJWTMissing, handlerErr)
+	assert.Equal(t, "public", rec.Body.String())
+}
+
+func TestJWTWithConfig_panics(t *testing.T) {
+	assert.PanicsWithValue(t, "echo: jwt middleware requires signing key", func() {
+		JWTWithConfig(JWTConfig{})
+	})
+	assert.Panics(t, func() {
+		JWT("string key")
+	})
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Panics(t, func() {
+		JWT(&p384.PublicKey)
+	})
+}
+
+func TestClaimStrings_UnmarshalJSON(t *testing.T) {
+	var rc RegisteredClaims
+	assert.NoError(t, json.Unmarshal([]byte(`{"aud":"api","exp":1700000000.5}`), &rc))
+	assert.Equal(t, ClaimStrings{"api"}, rc.Audience)
+	assert.Equal(t, time.Unix(1700000000, 5e8), rc.ExpiresAt.Time)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"aud":["a","b"]}`), &rc))
+	assert.Equal(t, ClaimStrings{"a", "b"}, rc.Audience)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"aud":[1]}`), &rc))
+	assert.Error(t, json.Unmarshal([]byte(`{"exp":"soon"}`), &rc))
+}
+
+func TestLoadJWKSFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rs", "use": "sig", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "es", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+			{"kty": "oct", "kid": "hs", "k": b64([]byte("secret"))},
+			{"kty": "RSA", "kid": "enc", "use": "enc"},
+		},
+	})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	keys, err := LoadJWKSFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, keys, 3)
+	assert.Equal(t, &rsaKey.PublicKey, keys["rs"])
+	assert.True(t, ecKey.PublicKey.Equal(keys["es"]))
+	assert.Equal(t, []byte("secret"), keys["hs"])
+
+	// tokens verify against the loaded key set
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+signJWT(t, "ES256", "es", ecKey, map[string]string{"sub": "jon"}))
+	c := e.NewContext(req, httptest.NewRecorder())
+	err = JWTWithConfig(JWTConfig{SigningKeys: keys})(func(c echo.Context) error {
+		assert.Equal(t, "jon", c.Get("user").(*RegisteredClaims).Subject)
+		return nil
+	})(c)
+	assert.NoError(t, err)
+}
+
+func TestParseJWKS_errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		given string
+	}{
+		{name: "invalid json", given: `{`},
+		{name: "missing kid", given: `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`},
+		{name: "unsupported kty", given: `{"keys":[{"kty":"OKP","kid":"a"}]}`},
+		{name: "unsupported curve", given: `{"keys":[{"kty":"EC","kid":"a","crv":"P-384"}]}`},
+		{name: "point not on curve", given: `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"AQ","y":"AQ"}]}`},
+		{name: "invalid rsa modulus", given: `{"keys":[{"kty":"RSA","kid":"a","n":"","e":"AQAB"}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(tc.given))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadJWKSFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// LoggerConfig defines the config for Logger middleware.
+	LoggerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Format is the log format which can be constructed using the following tags:
+		//
+		// - time_unix
+		// - time_unix_nano
+		// - time_rfc3339
+		// - time_rfc3339_nano
+		// - time_clf (Apache common log time, e.g. 10/Oct/2000:13:55:36 -0700)
+		// - time_custom
+		// - id (Request ID)
+		// - remote_ip
+		// - remote_user (Basic auth user name or "-")
+		// - uri
+		// - host
+		// - method
+		// - path
+		// - route (Registered route path, see `Context#Path()`)
+		// - protocol
+		// - referer
+		// - user_agent
+		// - status
+		// - error
+		// - latency (In nanoseconds)
+		// - latency_human (Human readable)
+		// - bytes_in (Bytes received)
+		// - bytes_out (Bytes sent)
+		// - bytes_out_clf (Bytes sent or "-" when nothing was sent)
+		// - header:<NAME>
+		// - query:<NAME>
+		//
+		// Example "${remote_ip} ${status}"
+		//
+		// Optional. Default value DefaultLoggerConfig.Format.
+		Format string
+
+		// CustomTimeFormat is the layout used by the `time_custom` tag.
+		// Optional. Default value "2006-01-02 15:04:05.00000".
+		CustomTimeFormat string
+
+		// Output is a writer where logs are written.
+		// Optional. Default value os.Stdout.
+		Output io.Writer
+	}
+
+	logTag struct {
+		literal string
+		name    string
+		param   string
+	}
+)
+
+const (
+	// CommonLogFormat is the Apache Common Log Format.
+	CommonLogFormat = `${remote_ip} - ${remote_user} [${time_clf}] "${method} ${uri} ${protocol}" ${status} ${bytes_out_clf}` + "\n"
+
+	// CombinedLogFormat is the Apache Combined Log Format.
+	CombinedLogFormat = `${remote_ip} - ${remote_user} [${time_clf}] "${method} ${uri} ${protocol}" ${status} ${bytes_out_clf} "${referer}" "${user_agent}"` + "\n"
+
+	timeCLF = "02/Jan/2006:15:04:05 -0700"
+)
+
+var (
+	// DefaultLoggerConfig is the default Logger middleware config.
+	DefaultLoggerConfig = LoggerConfig{
+		Skipper: DefaultSkipper,
+		Format: `{"time":"${time_rfc3339_nano}","id":"${id}","remote_ip":"${remote_ip}",` +
+			`"host":"${host}","method":"${method}","uri":"${uri}","user_agent":"${user_agent}",` +
+			`"status":${status},"error":"${error}","latency":${latency},"latency_human":"${latency_human}"` +
+			`,"bytes_in":${bytes_in},"bytes_out":${bytes_out}}` + "\n",
+		CustomTimeFormat: "2006-01-02 15:04:05.00000",
+		Output:           os.Stdout,
+	}
+)
+
+// Logger returns a middleware that logs HTTP requests.
+func Logger() echo.MiddlewareFunc {
+	return LoggerWithConfig(DefaultLoggerConfig)
+}
+
+// LoggerWithConfig returns a Logger middleware with config.
+// See: `Logger()`.
+func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultLoggerConfig.Skipper
+	}
+	if config.Format == "" {
+		config.Format = DefaultLoggerConfig.Format
+	}
+	if config.CustomTimeFormat == "" {
+		config.CustomTimeFormat = DefaultLoggerConfig.CustomTimeFormat
+	}
+	if config.Output == nil {
+		config.Output = DefaultLoggerConfig.Output
+	}
+
+	tags := parseLogFormat(config.Format)
+	pool := &sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 256))
+		},
+	}
+	var mu sync.Mutex
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			stop := time.Now()
+
+			buf := pool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer pool.Put(buf)
+
+			for _, t := range tags {
+				if t.name == "" {
+					buf.WriteString(t.literal)
+					continue
+				}
+				writeLogTag(buf, t, c, err, start, stop, config.CustomTimeFormat)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			_, err = config.Output.Write(buf.Bytes())
+			return err
+		}
+	}
+}
+
+// parseLogFormat splits format into literal text and `${tag}` placeholders once,
+// so that rendering a log line does not need to scan the format again.
+func parseLogFormat(format string) []logTag {
+	var tags []logTag
+	for {
+		i := strings.Index(format, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(format[i:], '}')
+		if j < 0 {
+			break
+		}
+		if i > 0 {
+			tags = append(tags, logTag{literal: format[:i]})
+		}
+		t := logTag{name: format[i+2 : i+j]}
+		if k := strings.IndexByte(t.name, ':'); k >= 0 {
+			t.name, t.param = t.name[:k+1], t.name[k+1:]
+		}
+		tags = append(tags, t)
+		format = format[i+j+1:]
+	}
+	if format != "" {
+		tags = append(tags, logTag{literal: format})
+	}
+	return tags
+}
+
+func writeLogTag(buf *bytes.Buffer, t logTag, c echo.Context, err error, start, stop time.Time, customTimeFormat string) {
+	req := c.Request()
+	res := c.Response()
+
+	switch t.name {
+	case "time_unix":
+		buf.WriteString(strconv.FormatInt(stop.Unix(), 10))
+	case "time_unix_nano":
+		buf.WriteString(strconv.FormatInt(stop.UnixNano(), 10))
+	case "time_rfc3339":
+		buf.WriteString(stop.Format(time.RFC3339))
+	case "time_rfc3339_nano":
+		buf.WriteString(stop.Format(time.RFC3339Nano))
+	case "time_clf":
+		buf.WriteString(start.Format(timeCLF))
+	case "time_custom":
+		buf.WriteString(stop.Format(customTimeFormat))
+	case "id":
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if id == "" {
+			id = res.Header().Get(echo.HeaderXRequestID)
+		}
+		buf.WriteString(id)
+	case "remote_ip":
+		buf.WriteString(c.RealIP())
+	case "remote_user":
+		if u, _, ok := req.BasicAuth(); ok && u != "" {
+			buf.WriteString(u)
+		} else {
+			buf.WriteString("-")
+		}
+	case "host":
+		buf.WriteString(req.Host)
+	case "uri":
+		buf.WriteString(req.RequestURI)
+	case "method":
+		buf.WriteString(req.Method)
+	case "path":
+		p := req.URL.Path
+		if p == "" {
+			p = "/"
+		}
+		buf.WriteString(p)
+	case "route":
+		buf.WriteString(c.Path())
+	case "protocol":
+		buf.WriteString(req.Proto)
+	case "referer":
+		buf.WriteString(req.Referer())
+	case "user_agent":
+		buf.WriteString(req.UserAgent())
+	case "status":
+		buf.WriteString(strconv.Itoa(res.Status))
+	case "error":
+		if err != nil {
+			// Error may contain invalid JSON e.g. `"`
+			b, _ := json.Marshal(err.Error())
+			buf.Write(b[1 : len(b)-1])
+		}
+	case "latency":
+		buf.WriteString(strconv.FormatInt(int64(stop.Sub(start)), 10))
+	case "latency_human":
+		buf.WriteString(stop.Sub(start).String())
+	case "bytes_in":
+		cl := req.Header.Get(echo.HeaderContentLength)
+		if cl == "" {
+			cl = "0"
+		}
+		buf.WriteString(cl)
+	case "bytes_out":
+		buf.WriteString(strconv.FormatInt(res.Size, 10))
+	case "bytes_out_clf":
+		if res.Size == 0 {
+			buf.WriteString("-")
+		} else {
+			buf.WriteString(strconv.FormatInt(res.Size, 10))
+		}
+	case "header:":
+		buf.WriteString(req.Header.Get(t.param))
+	case "query:":
+		buf.WriteString(c.QueryParams().Get(t.param))
+	}
+}
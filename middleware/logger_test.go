@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Use(LoggerWithConfig(LoggerConfig{Output: buf}))
+	e.GET("/users/1", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(echo.HeaderXRealIP, "127.0.0.1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	m := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "127.0.0.1", m["remote_ip"])
+	assert.Equal(t, float64(http.StatusOK), m["status"])
+	assert.Equal(t, float64(4), m["bytes_out"])
+	assert.Equal(t, "", m["error"])
+}
+
+func TestLoggerError(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	mw := LoggerWithConfig(LoggerConfig{
+		Format: `${status} "${error}"` + "\n",
+		Output: buf,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := mw(func(c echo.Context) error {
+		return errors.New(`error with "quotes"`)
+	})(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, `500 "error with \"quotes\""`+"\n", buf.String())
+}
+
+func TestLoggerTemplate(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Use(LoggerWithConfig(LoggerConfig{
+		Format: `route=${route} path=${path} method=${method} status=${status} ` +
+			`bytes_in=${bytes_in} bytes_out=${bytes_out} ua=${header:User-Agent} ` +
+			`lang=${query:lang} latency=${latency_human}`,
+		Output: buf,
+	}))
+	e.GET("/users/1", func(c echo.Context) error {
+		return c.String(http.StatusOK, "echo")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?lang=en", nil)
+	req.Header.Set("User-Agent", "echo-tests-agent")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	out := buf.String()
+	assert.Contains(t, out, "route=/users/1 ")
+	assert.Contains(t, out, "path=/users/1 ")
+	assert.Contains(t, out, "method=GET ")
+	assert.Contains(t, out, "status=200 ")
+	assert.Contains(t, out, "bytes_in=0 ")
+	assert.Contains(t, out, "bytes_out=4 ")
+	assert.Contains(t, out, "ua=echo-tests-agent ")
+	assert.Contains(t, out, "lang=en ")
+	assert.Contains(t, out, "latency=")
+	assert.NotContains(t, out, "${")
+}
+
+func TestLoggerCommonLogFormat(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	mw := LoggerWithConfig(LoggerConfig{Format: CombinedLogFormat, Output: buf})
+
+	req := httptest.NewRequest(http.MethodGet, "/apache_pb.gif?x=1", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://www.example.com/start.html")
+	req.Header.Set("User-Agent", "Mozilla/4.08")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.NoError(t, mw(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})(c))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "127.0.0.1 - frank ["), out)
+	assert.Contains(t, out, `] "GET /apache_pb.gif?x=1 HTTP/1.1" 204 - "http://www.example.com/start.html" "Mozilla/4.08"`+"\n")
+}
+
+func TestParseLogFormat(t *testing.T) {
+	tags := parseLogFormat("a ${status} b ${header:X-Test}${unterminated")
+	assert.Equal(t, []logTag{
+		{literal: "a "},
+		{name: "status"},
+		{literal: " b "},
+		{name: "header:", param: "X-Test"},
+		{literal: "${unterminated"},
+	}, tags)
+}
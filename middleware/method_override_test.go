@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodOverride(t *testing.T) {
+	testCases := []struct {
+		name         string
+		whenMethod   string
+		whenURL      string
+		whenForm     string
+		whenHeader   string
+		givenGetter  MethodOverrideGetter
+		expectMethod string
+	}{
+		{
+			name:         "header",
+			whenMethod:   http.MethodPost,
+			whenHeader:   http.MethodDelete,
+			expectMethod: http.MethodDelete,
+		},
+		{
+			name:         "no override",
+			whenMethod:   http.MethodPost,
+			expectMethod: http.MethodPost,
+		},
+		{
+			name:         "only POST is overridden",
+			whenMethod:   http.MethodGet,
+			whenHeader:   http.MethodDelete,
+			expectMethod: http.MethodGet,
+		},
+		{
+			name:         "form",
+			whenMethod:   http.MethodPost,
+			whenForm:     "_method=patch",
+			givenGetter:  MethodFromForm("_method"),
+			expectMethod: http.MethodPatch,
+		},
+		{
+			name:         "query",
+			whenMethod:   http.MethodPost,
+			whenURL:      "/?_method=PUT",
+			givenGetter:  MethodFromQuery("_method"),
+			expectMethod: http.MethodPut,
+		},
+		{
+			name:         "rejects GET",
+			whenMethod:   http.MethodPost,
+			whenForm:     "_method=GET",
+			givenGetter:  MethodFromForm("_method"),
+			expectMethod: http.MethodPost,
+		},
+		{
+			name:         "rejects head",
+			whenMethod:   http.MethodPost,
+			whenForm:     "_method=head",
+			givenGetter:  MethodFromForm("_method"),
+			expectMethod: http.MethodPost,
+		},
+		{
+			name:         "rejects OPTIONS",
+			whenMethod:   http.MethodPost,
+			whenHeader:   "OPTIONS",
+			expectMethod: http.MethodPost,
+		},
+		{
+			name:         "rejects trace",
+			whenMethod:   http.MethodPost,
+			whenHeader:   "trace",
+			expectMethod: http.MethodPost,
+		},
+		{
+			name:         "rejects FOO",
+			whenMethod:   http.MethodPost,
+			whenHeader:   "FOO",
+			expectMethod: http.MethodPost,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			m := MethodOverrideWithConfig(MethodOverrideConfig{Getter: tc.givenGetter})
+			h := m(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			url := "/"
+			if tc.whenURL != "" {
+				url = tc.whenURL
+			}
+			req := httptest.NewRequest(tc.whenMethod, url, strings.NewReader(tc.whenForm))
+			if tc.whenForm != "" {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			}
+			if tc.whenHeader != "" {
+				req.Header.Set(echo.HeaderXHTTPMethodOverride, tc.whenHeader)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			assert.NoError(t, h(c))
+			assert.Equal(t, tc.expectMethod, req.Method)
+		})
+	}
+}
+
+func TestMethodOverride_pre(t *testing.T) {
+	e := echo.New()
+	e.Pre(MethodOverride())
+	e.DELETE("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "deleted")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(echo.HeaderXHTTPMethodOverride, http.MethodDelete)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "deleted", rec.Body.String())
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// Skipper defines a function to skip middleware. Returning true skips processing
+	// the middleware.
+	Skipper func(c echo.Context) bool
+
+	// BeforeFunc defines a function which is executed just before the middleware.
+	BeforeFunc func(c echo.Context)
+
+	// rewriteRule rewrites URLs matching pattern to the replacement, which may
+	// refer to the capture groups of pattern as $1, $2, ...
+	rewriteRule struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}
+)
+
+// DefaultSkipper returns false which processes the middleware.
+func DefaultSkipper(echo.Context) bool {
+	return false
+}
+
+func captureTokens(pattern *regexp.Regexp, input string) *strings.Replacer {
+	groups := pattern.FindAllStringSubmatch(input, -1)
+	if groups == nil {
+		return nil
+	}
+	values := groups[0][1:]
+	replace := make([]string, 2*len(values))
+	// Replace higher group numbers first so "$1" does not eat into "$10".
+	for i := len(values) - 1; i >= 0; i-- {
+		j := 2 * (len(values) - 1 - i)
+		replace[j] = "$" + strconv.Itoa(i+1)
+		replace[j+1] = values[i]
+	}
+	return strings.NewReplacer(replace...)
+}
+
+// rewriteRules compiles glob rules, where "*" matches anything and a leading
+// "^" anchors the pattern, and regex rules into rewrite rules. More specific,
+// i.e. longer, patterns are tried first and the order is stable.
+func rewriteRules(rewrite map[string]string, regexRewrite map[*regexp.Regexp]string) []rewriteRule {
+	rules := make([]rewriteRule, 0, len(rewrite)+len(regexRewrite))
+	for k, v := range rewrite {
+		k = regexp.QuoteMeta(k)
+		k = strings.ReplaceAll(k, `\*`, "(.*?)")
+		if strings.HasPrefix(k, `\^`) {
+			k = strings.ReplaceAll(k, `\^`, "^")
+		}
+		k = k + "$"
+		rules = append(rules, rewriteRule{pattern: regexp.MustCompile(k), replacement: v})
+	}
+	for k, v := range regexRewrite {
+		rules = append(rules, rewriteRule{pattern: k, replacement: v})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		pi, pj := rules[i].pattern.String(), rules[j].pattern.String()
+		if len(pi) != len(pj) {
+			return len(pi) > len(pj)
+		}
+		return pi < pj
+	})
+	return rules
+}
+
+// rewriteURL rewrites the URL of req with the first matching rule. Both
+// URL.Path and URL.RawPath are updated so escaped paths stay consistent.
+func rewriteURL(rules []rewriteRule, req *http.Request) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	// Depending on how HTTP request is sent RequestURI could contain Scheme://Host/path or be just /path.
+	// We only want to use path part for rewriting and therefore trim prefix if it exists
+	rawURI := req.RequestURI
+	if rawURI == "" {
+		rawURI = req.URL.RequestURI()
+	}
+	if rawURI != "" && rawURI[0] != '/' {
+		prefix := ""
+		if req.URL.Scheme != "" {
+			prefix = req.URL.Scheme + "://"
+		}
+		if req.URL.Host != "" {
+			prefix += req.URL.Host // host or host:port
+		}
+		if prefix != "" {
+			rawURI = strings.TrimPrefix(rawURI, prefix)
+		}
+	}
+
+	for _, rule := range rules {
+		if replacer := captureTokens(rule.pattern, rawURI); replacer != nil {
+			url, err := req.URL.Parse(replacer.Replace(rule.replacement))
+			if err != nil {
+				return err
+			}
+			req.URL = url
+			return nil // rewrite only once
+		}
+	}
+	return nil
+}
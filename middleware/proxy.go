@@ -0,0 +1,382 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// ProxyConfig defines the config for Proxy middleware.
+	ProxyConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Balancer defines a load balancing technique.
+		// Required.
+		Balancer ProxyBalancer
+
+		// RetryCount defines the number of times a failed proxied request
+		// should be retried using the next available ProxyTarget. Request bodies
+		// are buffered in memory to be sent again when retries are enabled.
+		// Optional. Default value 0, meaning requests are never retried.
+		RetryCount int
+
+		// RetryBodyLimit is the largest request body, in bytes, buffered for
+		// retries. Requests with a larger body or without a Content-Length are
+		// not retried.
+		// Optional. Default value 1 MiB.
+		RetryBodyLimit int64
+
+		// RetryFilter defines a function used to determine if a failed request
+		// to a ProxyTarget should be retried. The err is the *echo.HTTPError
+		// the request failed with.
+		// Optional. By default only requests which failed to connect to the
+		// target are retried, as nothing of them has reached the target yet.
+		RetryFilter func(c echo.Context, err error) bool
+
+		// ErrorHandler defines a function which can be used to return custom
+		// errors from the Proxy middleware, err is an *echo.HTTPError with the
+		// cause as internal error.
+		// Optional. By default the error is returned to `Echo#HTTPErrorHandler`.
+		ErrorHandler func(c echo.Context, err error) error
+
+		// Rewrite defines URL path rewrite rules. The values captured in asterisk can be
+		// retrieved by index e.g. $1, $2 and so on.
+		// Examples:
+		// "/old":              "/new",
+		// "/api/*":            "/$1",
+		// "/js/*":             "/public/javascripts/$1",
+		// "/users/*/orders/*": "/user/$1/order/$2",
+		Rewrite map[string]string
+
+		// RegexRewrite defines rewrite rules using regexp.Regexp with captures
+		// Every capture group in the values can be retrieved by index e.g. $1, $2 and so on.
+		// Example:
+		// "^/old/[0-9]+/":     "/new",
+		// "^/api/.+?/(.*)":     "/v2/$1",
+		RegexRewrite map[*regexp.Regexp]string
+
+		// Context key to store selected ProxyTarget into context.
+		// Optional. Default value "target".
+		ContextKey string
+
+		// To customize the transport to remote.
+		// Examples: If custom TLS certificates are required.
+		Transport http.RoundTripper
+
+		// ModifyResponse defines function to modify response from ProxyTarget.
+		ModifyResponse func(*http.Response) error
+
+		// TrustForwardedHeaders keeps the `X-Real-IP` header of the request and
+		// otherwise sets it to `echo.Context#RealIP()`. Enable it only behind a
+		// proxy which sets `X-Forwarded-For` or `X-Real-IP`, as clients can send
+		// any value.
+		// Optional. By default `X-Real-IP` is the IP address of the connection.
+		TrustForwardedHeaders bool
+	}
+
+	// ProxyTarget defines the upstream target.
+	ProxyTarget struct {
+		Name string
+		URL  *url.URL
+		Meta echo.Map
+	}
+
+	// ProxyBalancer defines an interface to implement a load balancing technique.
+	ProxyBalancer interface {
+		AddTarget(*ProxyTarget) bool
+		RemoveTarget(string) bool
+		Next(echo.Context) *ProxyTarget
+	}
+
+	commonBalancer struct {
+		targets []*ProxyTarget
+		mutex   sync.RWMutex
+	}
+
+	// randomBalancer implements a random load balancing technique.
+	randomBalancer struct {
+		*commonBalancer
+		random *rand.Rand
+	}
+
+	// roundRobinBalancer implements a round-robin load balancing technique.
+	roundRobinBalancer struct {
+		*commonBalancer
+		// tracking the index on `targets` slice for the next `*ProxyTarget` to be used
+		i int
+	}
+)
+
+// StatusCodeContextCanceled is a custom HTTP status code for situations
+// where a client unexpectedly closed the connection to the server.
+// As there is no standard error code for "client closed connection", but
+// various well-known HTTP clients and server implement this HTTP code we use
+// 499 too instead of the more problematic 5xx, which does not allow to detect this situation
+const StatusCodeContextCanceled = 499
+
+var (
+	// DefaultProxyConfig is the default Proxy middleware config.
+	DefaultProxyConfig = ProxyConfig{
+		Skipper:        DefaultSkipper,
+		ContextKey:     "target",
+		RetryBodyLimit: 1 << 20,
+	}
+
+	errNoProxyTarget = errors.New("no proxy target available")
+)
+
+// NewRandomBalancer returns a random proxy balancer.
+func NewRandomBalancer(targets []*ProxyTarget) ProxyBalancer {
+	b := randomBalancer{commonBalancer: new(commonBalancer)}
+	b.targets = targets
+	b.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	return &b
+}
+
+// NewRoundRobinBalancer returns a round-robin proxy balancer.
+func NewRoundRobinBalancer(targets []*ProxyTarget) ProxyBalancer {
+	b := roundRobinBalancer{commonBalancer: new(commonBalancer)}
+	b.targets = targets
+	return &b
+}
+
+// AddTarget adds an upstream target to the list and returns `true`.
+//
+// However, if a target with the same name already exists then the operation is aborted returning `false`.
+func (b *commonBalancer) AddTarget(target *ProxyTarget) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, t := range b.targets {
+		if t.Name == target.Name {
+			return false
+		}
+	}
+	b.targets = append(b.targets, target)
+	return true
+}
+
+// RemoveTarget removes an upstream target from the list by name.
+//
+// Returns `true` on success, `false` if no target with the name is found.
+func (b *commonBalancer) RemoveTarget(name string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, t := range b.targets {
+		if t.Name == name {
+			b.targets = append(b.targets[:i], b.targets[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Next randomly returns an upstream target.
+//
+// Note: `nil` is returned in case upstream target list is empty.
+func (b *randomBalancer) Next(c echo.Context) *ProxyTarget {
+	// rand.Rand is not safe for concurrent use, hence the write lock.
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.targets) == 0 {
+		return nil
+	} else if len(b.targets) == 1 {
+		return b.targets[0]
+	}
+	return b.targets[b.random.Intn(len(b.targets))]
+}
+
+// Next returns an upstream target using round-robin technique.
+//
+// Note: `nil` is returned in case upstream target list is empty.
+func (b *roundRobinBalancer) Next(c echo.Context) *ProxyTarget {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.targets) == 0 {
+		return nil
+	} else if len(b.targets) == 1 {
+		return b.targets[0]
+	}
+	// reset the index if out of bounds
+	if b.i >= len(b.targets) {
+		b.i = 0
+	}
+	t := b.targets[b.i]
+	b.i++
+	return t
+}
+
+// Proxy returns a Proxy middleware.
+//
+// Proxy middleware forwards the request to upstream server using a configured load balancing technique.
+func Proxy(balancer ProxyBalancer) echo.MiddlewareFunc {
+	c := DefaultProxyConfig
+	c.Balancer = balancer
+	return ProxyWithConfig(c)
+}
+
+// ProxyWithConfig returns a Proxy middleware with config.
+//
+// The requests are forwarded with `httputil.ReverseProxy`, which also passes
+// through WebSocket connections, by hijacking `echo.Response`, and flushes
+// Server-Sent Events as they arrive.
+// See: `Proxy()`
+func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc {
+	if config.Balancer == nil {
+		panic("echo: proxy middleware requires balancer")
+	}
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultProxyConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultProxyConfig.ContextKey
+	}
+	if config.RetryFilter == nil {
+		config.RetryFilter = isProxyDialError
+	}
+	if config.RetryBodyLimit <= 0 {
+		config.RetryBodyLimit = DefaultProxyConfig.RetryBodyLimit
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(c echo.Context, err error) error {
+			return err
+		}
+	}
+	rules := rewriteRules(config.Rewrite, config.RegexRewrite)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			if err := rewriteURL(rules, req); err != nil {
+				return config.ErrorHandler(c, err)
+			}
+
+			// Fix header
+			if !config.TrustForwardedHeaders {
+				req.Header.Set(echo.HeaderXRealIP, remoteIP(req))
+			} else if req.Header.Get(echo.HeaderXRealIP) == "" {
+				req.Header.Set(echo.HeaderXRealIP, c.RealIP())
+			}
+			if req.Header.Get(echo.HeaderXForwardedProto) == "" {
+				req.Header.Set(echo.HeaderXForwardedProto, c.Scheme())
+			}
+			if req.Header.Get(echo.HeaderXForwardedHost) == "" {
+				req.Header.Set(echo.HeaderXForwardedHost, req.Host)
+			}
+			// X-Forwarded-For is appended to by httputil.ReverseProxy.
+
+			retries := config.RetryCount
+			// The body is consumed and closed by a failed attempt, so it's
+			// buffered to be sent again on retries. Bodies of unknown or too
+			// large size are sent once as they are.
+			var body []byte
+			if retries > 0 && req.Body != nil && req.Body != http.NoBody {
+				if req.ContentLength < 0 || req.ContentLength > config.RetryBodyLimit {
+					retries = 0
+				} else {
+					var err error
+					if body, err = ioutil.ReadAll(req.Body); err != nil {
+						return config.ErrorHandler(c, proxyError(err))
+					}
+					req.Body.Close()
+				}
+			}
+			for {
+				tgt := config.Balancer.Next(c)
+				if tgt == nil {
+					return config.ErrorHandler(c, proxyError(errNoProxyTarget))
+				}
+				c.Set(config.ContextKey, tgt)
+				if body != nil {
+					req.Body = ioutil.NopCloser(bytes.NewReader(body))
+				}
+
+				err := proxyHTTP(tgt, config).serve(res, req)
+				if err == nil {
+					return nil
+				}
+				// Retry only when nothing was sent to the client yet.
+				if retries > 0 && !res.Committed && config.RetryFilter(c, err) {
+					retries--
+					continue
+				}
+				return config.ErrorHandler(c, err)
+			}
+		}
+	}
+}
+
+type proxyHandler struct {
+	proxy *httputil.ReverseProxy
+	err   error
+}
+
+func proxyHTTP(tgt *ProxyTarget, config ProxyConfig) *proxyHandler {
+	h := new(proxyHandler)
+	h.proxy = httputil.NewSingleHostReverseProxy(tgt.URL)
+	h.proxy.ErrorHandler = func(resp http.ResponseWriter, req *http.Request, err error) {
+		desc := tgt.URL.String()
+		if tgt.Name != "" {
+			desc = fmt.Sprintf("%s(%s)", tgt.Name, tgt.URL.String())
+		}
+		// If the client canceled the request (usually by closing the connection), we can report a
+		// client error (4xx) instead of a server error (5xx) to correctly identify the situation.
+		if errors.Is(err, context.Canceled) {
+			h.err = echo.NewHTTPError(StatusCodeContextCanceled, "client closed connection").SetInternal(err)
+			return
+		}
+		h.err = proxyError(fmt.Errorf("remote %s unreachable, could not forward: %w", desc, err))
+	}
+	h.proxy.Transport = config.Transport
+	h.proxy.ModifyResponse = config.ModifyResponse
+	return h
+}
+
+// serve forwards the request and returns the error it failed with, the
+// response is left untouched in that case.
+func (h *proxyHandler) serve(w http.ResponseWriter, r *http.Request) error {
+	h.proxy.ServeHTTP(w, r)
+	return h.err
+}
+
+// remoteIP returns the IP address of the connection r was received on.
+func remoteIP(r *http.Request) string {
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return ip
+	}
+	return r.RemoteAddr
+}
+
+func proxyError(err error) error {
+	return &echo.HTTPError{
+		Code:     echo.ErrBadGateway.Code,
+		Message:  echo.ErrBadGateway.Message,
+		Internal: err,
+	}
+}
+
+// isProxyDialError reports whether the request failed because the target
+// could not be connected to.
+func isProxyDialError(c echo.Context, err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
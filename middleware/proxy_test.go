@@ -0,0 +1,398 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProxyTarget(t *testing.T, name string, h http.HandlerFunc) (*ProxyTarget, *httptest.Server) {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return &ProxyTarget{Name: name, URL: u}, srv
+}
+
+func nameHandler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	}
+}
+
+func proxyRequest(e *echo.Echo, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProxy(t *testing.T) {
+	t1, _ := newProxyTarget(t, "target 1", nameHandler("target 1"))
+	t2, _ := newProxyTarget(t, "target 2", nameHandler("target 2"))
+	targets := []*ProxyTarget{t1, t2}
+
+	// Random
+	e := echo.New()
+	e.Use(Proxy(NewRandomBalancer(targets)))
+	rec := proxyRequest(e, "/")
+	assert.Contains(t, []string{"target 1", "target 2"}, rec.Body.String())
+
+	// Round-robin
+	e = echo.New()
+	e.Use(Proxy(NewRoundRobinBalancer(targets)))
+	assert.Equal(t, "target 1", proxyRequest(e, "/").Body.String())
+	assert.Equal(t, "target 2", proxyRequest(e, "/").Body.String())
+	assert.Equal(t, "target 1", proxyRequest(e, "/").Body.String())
+
+	// Selected target is stored in the context
+	e = echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			assert.Equal(t, t1, c.Get("target"))
+			return err
+		}
+	}, Proxy(NewRoundRobinBalancer([]*ProxyTarget{t1})))
+	proxyRequest(e, "/")
+}
+
+func TestProxyBalancer_targets(t *testing.T) {
+	t1, _ := newProxyTarget(t, "target 1", nameHandler("target 1"))
+	t2, _ := newProxyTarget(t, "target 2", nameHandler("target 2"))
+
+	b := NewRoundRobinBalancer(nil)
+	e := echo.New()
+	e.Use(Proxy(b))
+
+	rec := proxyRequest(e, "/")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+
+	assert.True(t, b.AddTarget(t1))
+	assert.False(t, b.AddTarget(t1))
+	assert.True(t, b.AddTarget(t2))
+	assert.Equal(t, "target 1", proxyRequest(e, "/").Body.String())
+	assert.Equal(t, "target 2", proxyRequest(e, "/").Body.String())
+
+	assert.True(t, b.RemoveTarget("target 1"))
+	assert.False(t, b.RemoveTarget("unknown"))
+	assert.Equal(t, "target 2", proxyRequest(e, "/").Body.String())
+	assert.Equal(t, "target 2", proxyRequest(e, "/").Body.String())
+}
+
+func TestProxyRewrite(t *testing.T) {
+	tgt, _ := newProxyTarget(t, "", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+	})
+
+	testCases := []struct {
+		whenURL   string
+		expectURL string
+	}{
+		{whenURL: "/api/users", expectURL: "/users?"},
+		{whenURL: "/js/main.js", expectURL: "/public/javascripts/main.js?"},
+		{whenURL: "/old", expectURL: "/new?"},
+		{whenURL: "/users/jack/orders/1", expectURL: "/user/jack/order/1?"},
+		{whenURL: "/user/jill/order/T%2F1%2Fabc%252F", expectURL: "/user/jill/order/T%2F1%2Fabc%252F?"},
+		{whenURL: "/api/new%20users?limit=10", expectURL: "/new%20users?limit=10"},
+		{whenURL: "/v1/123/items", expectURL: "/v2/items?"},
+	}
+
+	e := echo.New()
+	e.Use(ProxyWithConfig(ProxyConfig{
+		Balancer: NewRoundRobinBalancer([]*ProxyTarget{tgt}),
+		Rewrite: map[string]string{
+			"/old":              "/new",
+			"/api/*":            "/$1",
+			"/js/*":             "/public/javascripts/$1",
+			"/users/*/orders/*": "/user/$1/order/$2",
+		},
+		RegexRewrite: map[*regexp.Regexp]string{
+			regexp.MustCompile("^/v1/[0-9]+/(.*)"): "/v2/$1",
+		},
+	}))
+
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			rec := proxyRequest(e, tc.whenURL)
+			assert.Equal(t, tc.expectURL, rec.Body.String())
+		})
+	}
+}
+
+func TestProxyHeaders(t *testing.T) {
+	tgt, _ := newProxyTarget(t, "", func(w http.ResponseWriter, r *http.Request) {
+		for _, h := range []string{echo.HeaderXRealIP, echo.HeaderXForwardedFor, echo.HeaderXForwardedProto, echo.HeaderXForwardedHost} {
+			fmt.Fprintf(w, "%s=%s\n", h, r.Header.Get(h))
+		}
+		fmt.Fprintf(w, "Host=%s\n", r.Host)
+	})
+
+	testCases := []struct {
+		name         string
+		trusted      bool
+		header       map[string]string
+		expectRealIP string
+		expectXFF    string
+	}{
+		{name: "no forwarded headers", expectRealIP: "203.0.113.1", expectXFF: "203.0.113.1"},
+		{
+			name:         "spoofed headers are ignored",
+			header:       map[string]string{echo.HeaderXRealIP: "10.0.0.1", echo.HeaderXForwardedFor: "10.0.0.2"},
+			expectRealIP: "203.0.113.1",
+			expectXFF:    "10.0.0.2, 203.0.113.1",
+		},
+		{
+			name:         "trusted X-Forwarded-For",
+			trusted:      true,
+			header:       map[string]string{echo.HeaderXForwardedFor: "10.0.0.2"},
+			expectRealIP: "10.0.0.2",
+			expectXFF:    "10.0.0.2, 203.0.113.1",
+		},
+		{
+			name:         "trusted X-Real-IP",
+			trusted:      true,
+			header:       map[string]string{echo.HeaderXRealIP: "10.0.0.1"},
+			expectRealIP: "10.0.0.1",
+			expectXFF:    "203.0.113.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(ProxyWithConfig(ProxyConfig{
+				Balancer:              NewRoundRobinBalancer([]*ProxyTarget{tgt}),
+				TrustForwardedHeaders: tc.trusted,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			req.Host = "example.com"
+			req.RemoteAddr = "203.0.113.1:1234"
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, "X-Real-IP="+tc.expectRealIP+"\n"+
+				"X-Forwarded-For="+tc.expectXFF+"\n"+
+				"X-Forwarded-Proto=http\n"+
+				"X-Forwarded-Host=example.com\n"+
+				"Host=example.com\n", rec.Body.String())
+		})
+	}
+}
+
+func TestProxyRetry(t *testing.T) {
+	good, _ := newProxyTarget(t, "good", nameHandler("good"))
+	bad, badSrv := newProxyTarget(t, "bad", nameHandler("bad"))
+	badSrv.Close() // connections are refused
+
+	testCases := []struct {
+		name       string
+		retryCount int
+		filter     func(c echo.Context, err error) bool
+		expectCode int
+		expectBody string
+	}{
+		{name: "no retries", expectCode: http.StatusBadGateway},
+		{name: "retry on next target", retryCount: 1, expectCode: http.StatusOK, expectBody: "good"},
+		{
+			name:       "retry filter declines",
+			retryCount: 1,
+			filter:     func(c echo.Context, err error) bool { return false },
+			expectCode: http.StatusBadGateway,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(ProxyWithConfig(ProxyConfig{
+				Balancer:    NewRoundRobinBalancer([]*ProxyTarget{bad, good}),
+				RetryCount:  tc.retryCount,
+				RetryFilter: tc.filter,
+			}))
+			rec := proxyRequest(e, "/")
+			assert.Equal(t, tc.expectCode, rec.Code)
+			if tc.expectBody != "" {
+				assert.Equal(t, tc.expectBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRetryWithBody(t *testing.T) {
+	good, _ := newProxyTarget(t, "good", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	})
+	bad, badSrv := newProxyTarget(t, "bad", nameHandler("bad"))
+	badSrv.Close()
+
+	testCases := []struct {
+		name       string
+		body       io.Reader
+		expectCode int
+		expectBody string
+	}{
+		{name: "buffered", body: strings.NewReader("payload"), expectCode: http.StatusOK, expectBody: "POST payload"},
+		{name: "over the limit", body: strings.NewReader("too large payload"), expectCode: http.StatusBadGateway},
+		// Sent chunked, as the size is unknown.
+		{name: "unknown length", body: io.MultiReader(strings.NewReader("payload")), expectCode: http.StatusBadGateway},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(ProxyWithConfig(ProxyConfig{
+				Balancer:       NewRoundRobinBalancer([]*ProxyTarget{bad, good}),
+				RetryCount:     1,
+				RetryBodyLimit: 10,
+			}))
+			// A served request, as its body can not be read anymore once closed.
+			srv := httptest.NewServer(e)
+			defer srv.Close()
+
+			res, err := http.Post(srv.URL, echo.MIMETextPlain, tc.body)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tc.expectCode, res.StatusCode)
+			if tc.expectBody != "" {
+				body, _ := io.ReadAll(res.Body)
+				assert.Equal(t, tc.expectBody, string(body))
+			}
+		})
+	}
+}
+
+func TestProxyErrorHandler(t *testing.T) {
+	bad, badSrv := newProxyTarget(t, "bad", nameHandler("bad"))
+	badSrv.Close()
+
+	var proxyErr error
+	e := echo.New()
+	e.Use(ProxyWithConfig(ProxyConfig{
+		Balancer: NewRoundRobinBalancer([]*ProxyTarget{bad}),
+		ErrorHandler: func(c echo.Context, err error) error {
+			proxyErr = err
+			return echo.NewHTTPError(http.StatusTeapot)
+		},
+	}))
+	rec := proxyRequest(e, "/")
+
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	var he *echo.HTTPError
+	if assert.ErrorAs(t, proxyErr, &he) {
+		assert.Equal(t, http.StatusBadGateway, he.Code)
+		assert.True(t, isProxyDialError(nil, proxyErr))
+	}
+}
+
+func TestProxyWebSocket(t *testing.T) {
+	tgt, _ := newProxyTarget(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(echo.HeaderUpgrade) != "websocket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		brw.Flush()
+		// echo one line back
+		line, _ := brw.ReadString('\n')
+		brw.WriteString("echo: " + line)
+		brw.Flush()
+	})
+
+	e := echo.New()
+	e.Use(Proxy(NewRoundRobinBalancer([]*ProxyTarget{tgt})))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+
+	fmt.Fprint(conn, "hello\n")
+	line, err := br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "echo: hello\n", line)
+}
+
+func TestProxySSE(t *testing.T) {
+	release := make(chan struct{})
+	tgt, _ := newProxyTarget(t, "", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(echo.HeaderContentType, "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprint(w, "data: second\n\n")
+	})
+
+	e := echo.New()
+	e.Use(Proxy(NewRoundRobinBalancer([]*ProxyTarget{tgt})))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/events")
+	if !assert.NoError(t, err) {
+		close(release)
+		return
+	}
+	defer res.Body.Close()
+
+	// the first event arrives while the target is still streaming
+	br := bufio.NewReader(res.Body)
+	line, err := br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "data: first\n", line)
+
+	close(release)
+	rest, _ := io.ReadAll(br)
+	assert.Equal(t, "\ndata: second\n\n", string(rest))
+}
+
+func TestRewriteRules(t *testing.T) {
+	rules := rewriteRules(map[string]string{
+		"/*":       "/all/$1",
+		"/users/*": "/u/$1",
+		"^/a*":     "/b$1",
+	}, nil)
+
+	var patterns []string
+	for _, r := range rules {
+		patterns = append(patterns, r.pattern.String())
+	}
+	assert.Equal(t, []string{"/users/(.*?)$", "^/a(.*?)$", "/(.*?)$"}, patterns)
+
+	re := regexp.MustCompile(strings.Repeat("(.)", 10))
+	assert.Equal(t, "j-a", captureTokens(re, "abcdefghij").Replace("$10-$1"))
+}
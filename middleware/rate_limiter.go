@@ -0,0 +1,343 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// RateLimiterStore is the interface to be implemented by custom stores.
+	RateLimiterStore interface {
+		// Allow takes a token for identifier and reports whether the request
+		// may proceed along with the state of the limit.
+		Allow(identifier string) (RateLimitResult, error)
+	}
+
+	// RateLimitResult describes the outcome of `RateLimiterStore.Allow()`.
+	RateLimitResult struct {
+		// Allowed reports whether the request may proceed.
+		Allowed bool
+		// Limit is the number of requests allowed in a burst.
+		Limit int
+		// Remaining is the number of requests left in the current burst.
+		Remaining int
+		// ResetAfter is the time until the full burst is available again.
+		ResetAfter time.Duration
+		// RetryAfter is the time until the next request is allowed when the
+		// request is denied.
+		RetryAfter time.Duration
+	}
+
+	// Extractor is used to extract data from echo.Context.
+	Extractor func(c echo.Context) (string, error)
+
+	// RateLimiterConfig defines the configuration for the rate limiter.
+	RateLimiterConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// BeforeFunc defines a function which is executed just before the middleware.
+		BeforeFunc BeforeFunc
+
+		// IdentifierExtractor uses echo.Context to extract the identifier for a visitor.
+		// Optional. Default value ExtractIdentifierFromRemoteAddr.
+		IdentifierExtractor Extractor
+
+		// Store defines a store for the rate limiter.
+		// Required.
+		Store RateLimiterStore
+
+		// ErrorHandler provides a handler to be called when IdentifierExtractor
+		// returns an error.
+		// Optional. By default ErrExtractorError is returned.
+		ErrorHandler func(c echo.Context, err error) error
+
+		// DenyHandler provides a handler to be called when the rate limiter
+		// denies access, err is set when the store failed.
+		// Optional. By default `echo.ErrTooManyRequests` is returned.
+		DenyHandler func(c echo.Context, identifier string, err error) error
+
+		// DisableHeaders disables the `RateLimit-*` and `Retry-After` headers.
+		// Optional. Default value false.
+		DisableHeaders bool
+	}
+
+	// RateLimiterMemoryStore is the built-in store implementation for
+	// RateLimiter. It keeps a token bucket per identifier in memory.
+	RateLimiterMemoryStore struct {
+		mutex    sync.Mutex
+		visitors map[string]*visitor
+
+		rate      float64 // tokens per second
+		burst     int
+		expiresIn time.Duration
+
+		lastCleanup time.Time
+		timeNow     func() time.Time
+	}
+
+	// RateLimiterMemoryStoreConfig represents configuration for RateLimiterMemoryStore.
+	RateLimiterMemoryStoreConfig struct {
+		// Rate is the number of requests per second a visitor may make.
+		Rate float64
+
+		// Burst is the maximum number of requests a visitor may make at once.
+		// Optional. Default value is Rate rounded down, but at least 1.
+		Burst int
+
+		// ExpiresIn is the duration after which an idle visitor is removed
+		// from the store.
+		// Optional. Default value 3 minutes.
+		ExpiresIn time.Duration
+	}
+
+	// visitor is a token bucket.
+	visitor struct {
+		tokens   float64
+		lastSeen time.Time
+	}
+)
+
+var (
+	// ErrExtractorError denotes an error raised when the extractor function is unsuccessful.
+	ErrExtractorError = echo.NewHTTPError(http.StatusForbidden, "error while extracting identifier")
+
+	// DefaultRateLimiterConfig defines default values for RateLimiterConfig.
+	DefaultRateLimiterConfig = RateLimiterConfig{
+		Skipper:             DefaultSkipper,
+		IdentifierExtractor: ExtractIdentifierFromRemoteAddr,
+		ErrorHandler: func(c echo.Context, err error) error {
+			return &echo.HTTPError{
+				Code:     ErrExtractorError.Code,
+				Message:  ErrExtractorError.Message,
+				Internal: err,
+			}
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return echo.ErrTooManyRequests
+		},
+	}
+
+	// DefaultRateLimiterMemoryStoreConfig provides default configuration values for RateLimiterMemoryStore.
+	DefaultRateLimiterMemoryStoreConfig = RateLimiterMemoryStoreConfig{
+		ExpiresIn: 3 * time.Minute,
+	}
+)
+
+// ExtractIdentifierFromRemoteAddr identifies visitors by the IP address of
+// the connection, which clients can not choose freely.
+func ExtractIdentifierFromRemoteAddr(c echo.Context) (string, error) {
+	ra := c.Request().RemoteAddr
+	if ip, _, err := net.SplitHostPort(ra); err == nil {
+		return ip, nil
+	}
+	return ra, nil
+}
+
+// ExtractIdentifierFromRealIP identifies visitors by `echo.Context#RealIP()`.
+// Use it only behind a proxy which sets `X-Forwarded-For` or `X-Real-IP`, as
+// clients can send any value in these headers to evade the limit otherwise.
+func ExtractIdentifierFromRealIP(c echo.Context) (string, error) {
+	return c.RealIP(), nil
+}
+
+// ExtractIdentifierFromHeader returns an Extractor identifying visitors by the
+// value of the request header name, e.g. an API key.
+func ExtractIdentifierFromHeader(name string) Extractor {
+	return func(c echo.Context) (string, error) {
+		id := c.Request().Header.Get(name)
+		if id == "" {
+			return "", fmt.Errorf("missing header %s", name)
+		}
+		return id, nil
+	}
+}
+
+// ExtractIdentifierFromContext returns an Extractor identifying visitors by the
+// value stored under key in the context, e.g. a user ID set by an
+// authentication middleware. Values which are not strings are formatted with
+// fmt.Sprint.
+func ExtractIdentifierFromContext(key string) Extractor {
+	return func(c echo.Context) (string, error) {
+		v := c.Get(key)
+		if v == nil {
+			return "", fmt.Errorf("missing context value %s", key)
+		}
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	}
+}
+
+// RateLimiter returns a rate limiting middleware
+//
+//	e := echo.New()
+//
+//	limiterStore := middleware.NewRateLimiterMemoryStore(20)
+//
+//	e.GET("/rate-limited", func(c echo.Context) error {
+//		return c.String(http.StatusOK, "test")
+//	}, RateLimiter(limiterStore))
+func RateLimiter(store RateLimiterStore) echo.MiddlewareFunc {
+	config := DefaultRateLimiterConfig
+	config.Store = store
+
+	return RateLimiterWithConfig(config)
+}
+
+// RateLimiterWithConfig returns a rate limiting middleware with config.
+// See: `RateLimiter()`.
+func RateLimiterWithConfig(config RateLimiterConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRateLimiterConfig.Skipper
+	}
+	if config.IdentifierExtractor == nil {
+		config.IdentifierExtractor = DefaultRateLimiterConfig.IdentifierExtractor
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = DefaultRateLimiterConfig.ErrorHandler
+	}
+	if config.DenyHandler == nil {
+		config.DenyHandler = DefaultRateLimiterConfig.DenyHandler
+	}
+	if config.Store == nil {
+		panic("echo: rate limiter store is required")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			if config.BeforeFunc != nil {
+				config.BeforeFunc(c)
+			}
+
+			identifier, err := config.IdentifierExtractor(c)
+			if err != nil {
+				return config.ErrorHandler(c, err)
+			}
+
+			result, err := config.Store.Allow(identifier)
+			if err != nil {
+				return config.DenyHandler(c, identifier, err)
+			}
+
+			if !config.DisableHeaders {
+				h := c.Response().Header()
+				h.Set(echo.HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+				h.Set(echo.HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+				h.Set(echo.HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+				if !result.Allowed {
+					h.Set(echo.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				}
+			}
+			if !result.Allowed {
+				return config.DenyHandler(c, identifier, nil)
+			}
+			return next(c)
+		}
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds as the headers do not allow fractions.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// NewRateLimiterMemoryStore returns an instance of RateLimiterMemoryStore with
+// the provided rate (as req/s). Burst and ExpiresIn will be set to default values.
+//
+// Example (with 20 requests/sec):
+//
+//	limiterStore := middleware.NewRateLimiterMemoryStore(20)
+func NewRateLimiterMemoryStore(rate float64) (store *RateLimiterMemoryStore) {
+	return NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{
+		Rate: rate,
+	})
+}
+
+// NewRateLimiterMemoryStoreWithConfig returns an instance of RateLimiterMemoryStore
+// with the provided configuration. Rate must be provided. Burst will be set to
+// the rounded down value of the configured rate if not provided or set to 0.
+//
+// Example:
+//
+//	limiterStore := middleware.NewRateLimiterMemoryStoreWithConfig(
+//		middleware.RateLimiterMemoryStoreConfig{Rate: 50, Burst: 200, ExpiresIn: 5 * time.Minute},
+//	)
+func NewRateLimiterMemoryStoreWithConfig(config RateLimiterMemoryStoreConfig) (store *RateLimiterMemoryStore) {
+	if config.Rate <= 0 {
+		panic("echo: rate limiter memory store rate must be positive")
+	}
+	store = &RateLimiterMemoryStore{
+		visitors:  make(map[string]*visitor),
+		rate:      config.Rate,
+		burst:     config.Burst,
+		expiresIn: config.ExpiresIn,
+		timeNow:   time.Now,
+	}
+	if store.burst == 0 {
+		store.burst = int(math.Max(1, math.Floor(config.Rate)))
+	}
+	if store.expiresIn == 0 {
+		store.expiresIn = DefaultRateLimiterMemoryStoreConfig.ExpiresIn
+	}
+	store.lastCleanup = store.timeNow()
+	return
+}
+
+// Allow implements RateLimiterStore.Allow.
+func (store *RateLimiterMemoryStore) Allow(identifier string) (RateLimitResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.timeNow()
+	v, exists := store.visitors[identifier]
+	if !exists {
+		v = &visitor{tokens: float64(store.burst)}
+		store.visitors[identifier] = v
+	} else {
+		elapsed := now.Sub(v.lastSeen).Seconds()
+		v.tokens = math.Min(float64(store.burst), v.tokens+elapsed*store.rate)
+	}
+	v.lastSeen = now
+	if now.Sub(store.lastCleanup) > store.expiresIn {
+		store.cleanupStaleVisitors(now)
+	}
+
+	result := RateLimitResult{Limit: store.burst}
+	if v.tokens >= 1 {
+		v.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = store.duration(1 - v.tokens)
+	}
+	result.Remaining = int(v.tokens)
+	result.ResetAfter = store.duration(float64(store.burst) - v.tokens)
+	return result, nil
+}
+
+// duration returns the time it takes to refill the given number of tokens.
+func (store *RateLimiterMemoryStore) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / store.rate * float64(time.Second))
+}
+
+// cleanupStaleVisitors removes visitors idle for longer than expiresIn.
+func (store *RateLimiterMemoryStore) cleanupStaleVisitors(now time.Time) {
+	for id, v := range store.visitors {
+		if now.Sub(v.lastSeen) > store.expiresIn {
+			delete(store.visitors, id)
+		}
+	}
+	store.lastCleanup = now
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	store := NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{Rate: 1, Burst: 3})
+	now := time.Now()
+	store.timeNow = func() time.Time { return now }
+	mw := RateLimiter(store)
+
+	testCases := []struct {
+		id            string
+		expectCode    int
+		expectRemain  string
+		expectRetryIn string
+	}{
+		{id: "127.0.0.1", expectCode: http.StatusOK, expectRemain: "2"},
+		{id: "127.0.0.1", expectCode: http.StatusOK, expectRemain: "1"},
+		{id: "127.0.0.1", expectCode: http.StatusOK, expectRemain: "0"},
+		{id: "127.0.0.1", expectCode: http.StatusTooManyRequests, expectRemain: "0", expectRetryIn: "1"},
+		{id: "127.0.0.2", expectCode: http.StatusOK, expectRemain: "2"},
+	}
+
+	for i, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.id + ":1234"
+		// headers set by the client do not identify it
+		req.Header.Set(echo.HeaderXRealIP, fmt.Sprintf("10.0.0.%d", i))
+		req.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("10.0.1.%d", i))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := mw(handler)(c)
+		if tc.expectCode == http.StatusOK {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		} else {
+			assert.Equal(t, echo.ErrTooManyRequests, err)
+		}
+		assert.Equal(t, "3", rec.Header().Get(echo.HeaderRateLimitLimit))
+		assert.Equal(t, tc.expectRemain, rec.Header().Get(echo.HeaderRateLimitRemaining))
+		assert.Equal(t, tc.expectRetryIn, rec.Header().Get(echo.HeaderRetryAfter))
+	}
+}
+
+func TestRateLimiterWithConfig(t *testing.T) {
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	mw := RateLimiterWithConfig(RateLimiterConfig{
+		IdentifierExtractor: ExtractIdentifierFromHeader("X-Api-Key"),
+		Store:               NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{Rate: 1, Burst: 1}),
+		ErrorHandler: func(c echo.Context, err error) error {
+			return c.String(http.StatusBadRequest, err.Error())
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return c.String(http.StatusForbidden, "denied "+identifier)
+		},
+		DisableHeaders: true,
+	})
+
+	testCases := []struct {
+		key        string
+		expectCode int
+		expectBody string
+	}{
+		{key: "", expectCode: http.StatusBadRequest, expectBody: "missing header X-Api-Key"},
+		{key: "abc", expectCode: http.StatusOK, expectBody: "test"},
+		{key: "abc", expectCode: http.StatusForbidden, expectBody: "denied abc"},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.key != "" {
+			req.Header.Set("X-Api-Key", tc.key)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.NoError(t, mw(handler)(c))
+		assert.Equal(t, tc.expectCode, rec.Code)
+		assert.Equal(t, tc.expectBody, rec.Body.String())
+		assert.Empty(t, rec.Header().Get(echo.HeaderRateLimitLimit))
+	}
+}
+
+type failingRateLimiterStore struct{}
+
+func (failingRateLimiterStore) Allow(string) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store down")
+}
+
+func TestRateLimiterWithConfig_errors(t *testing.T) {
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	// extractor errors
+	mw := RateLimiterWithConfig(RateLimiterConfig{
+		IdentifierExtractor: ExtractIdentifierFromContext("user"),
+		Store:               NewRateLimiterMemoryStore(10),
+	})
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	err := mw(handler)(c)
+	var he *echo.HTTPError
+	if assert.ErrorAs(t, err, &he) {
+		assert.Equal(t, http.StatusForbidden, he.Code)
+		assert.EqualError(t, he.Internal, "missing context value user")
+	}
+	assert.Nil(t, ErrExtractorError.Internal)
+
+	// context values of any type identify the visitor
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set("user", 42)
+	assert.NoError(t, mw(handler)(c))
+
+	// store errors are passed to the deny handler
+	var denyErr error
+	mw = RateLimiterWithConfig(RateLimiterConfig{
+		Store: failingRateLimiterStore{},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			denyErr = err
+			return echo.ErrTooManyRequests
+		},
+	})
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Equal(t, echo.ErrTooManyRequests, mw(handler)(c))
+	assert.EqualError(t, denyErr, "store down")
+
+	assert.Panics(t, func() {
+		RateLimiterWithConfig(RateLimiterConfig{})
+	})
+}
+
+func TestExtractIdentifierFromRemoteAddr(t *testing.T) {
+	e := echo.New()
+	testCases := []struct {
+		remoteAddr string
+		expect     string
+	}{
+		{remoteAddr: "203.0.113.1:1234", expect: "203.0.113.1"},
+		{remoteAddr: "[2001:db8::1]:1234", expect: "2001:db8::1"},
+		{remoteAddr: "/tmp/echo.sock", expect: "/tmp/echo.sock"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		id, err := ExtractIdentifierFromRemoteAddr(e.NewContext(req, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expect, id)
+	}
+}
+
+func TestRateLimiterMemoryStore_Allow(t *testing.T) {
+	store := NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{Rate: 2, Burst: 2, ExpiresIn: time.Minute})
+	now := time.Now()
+	store.timeNow = func() time.Time { return now }
+
+	r, _ := store.Allow("a")
+	assert.True(t, r.Allowed)
+	r, _ = store.Allow("a")
+	assert.True(t, r.Allowed)
+	assert.Equal(t, time.Second, r.ResetAfter)
+	r, _ = store.Allow("a")
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 500*time.Millisecond, r.RetryAfter)
+
+	// refills at the configured rate
+	now = now.Add(500 * time.Millisecond)
+	r, _ = store.Allow("a")
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 0, r.Remaining)
+}
+
+func TestRateLimiterMemoryStore_cleanupStaleVisitors(t *testing.T) {
+	store := NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{Rate: 1, ExpiresIn: time.Minute})
+	now := time.Now()
+	store.timeNow = func() time.Time { return now }
+
+	store.Allow("a")
+	now = now.Add(30 * time.Second)
+	store.Allow("b")
+	assert.Len(t, store.visitors, 2)
+
+	now = now.Add(45 * time.Second)
+	store.Allow("b")
+	assert.Len(t, store.visitors, 1)
+	assert.Contains(t, store.visitors, "b")
+}
+
+func TestNewRateLimiterMemoryStore(t *testing.T) {
+	store := NewRateLimiterMemoryStore(0.5)
+	assert.Equal(t, 1, store.burst)
+	assert.Equal(t, 3*time.Minute, store.expiresIn)
+
+	store = NewRateLimiterMemoryStore(20)
+	assert.Equal(t, 20, store.burst)
+
+	assert.Panics(t, func() {
+		NewRateLimiterMemoryStore(0)
+	})
+}
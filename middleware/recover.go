@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// LogErrorFunc defines a function for custom logging in the middleware.
+	// The returned error is passed on to the error handler; returning nil
+	// means the panic has been fully dealt with.
+	LogErrorFunc func(c echo.Context, err error, stack []byte) error
+
+	// RecoverConfig defines the config for Recover middleware.
+	RecoverConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Size of the stack to be printed.
+		// Optional. Default value 4KB.
+		StackSize int
+
+		// DisableStackAll disables formatting stack traces of all other goroutines
+		// into buffer after the trace for the current goroutine.
+		// Optional. Default value false.
+		DisableStackAll bool
+
+		// DisablePrintStack disables printing stack trace.
+		// Optional. Default value as false.
+		DisablePrintStack bool
+
+		// LogErrorFunc defines a function for custom logging in the middleware.
+		// If it's set you don't need to provide DisablePrintStack.
+		LogErrorFunc LogErrorFunc
+
+		// DisableErrorHandler disables the call to the centralized HTTPErrorHandler.
+		// The recovered error is then returned up the middleware chain.
+		// Optional. Default value false.
+		DisableErrorHandler bool
+	}
+)
+
+var (
+	// DefaultRecoverConfig is the default Recover middleware config.
+	DefaultRecoverConfig = RecoverConfig{
+		Skipper:             DefaultSkipper,
+		StackSize:           4 << 10, // 4 KB
+		DisableStackAll:     false,
+		DisablePrintStack:   false,
+		LogErrorFunc:        nil,
+		DisableErrorHandler: false,
+	}
+)
+
+// Recover returns a middleware which recovers from panics anywhere in the chain
+// and handles the control to the centralized HTTPErrorHandler.
+func Recover() echo.MiddlewareFunc {
+	return RecoverWithConfig(DefaultRecoverConfig)
+}
+
+// RecoverWithConfig returns a Recover middleware with config.
+// See: `Recover()`.
+func RecoverWithConfig(config RecoverConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRecoverConfig.Skipper
+	}
+	if config.StackSize == 0 {
+		config.StackSize = DefaultRecoverConfig.StackSize
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (returnErr error) {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			defer func() {
+				if r := recover(); r != nil {
+					if r == http.ErrAbortHandler {
+						// net/http uses this sentinel to abort the response silently,
+						// so it must reach the server untouched.
+						panic(r)
+					}
+					err, ok := r.(error)
+					if !ok {
+						err = fmt.Errorf("%v", r)
+					}
+					var stack []byte
+					if config.LogErrorFunc != nil || !config.DisablePrintStack {
+						stack = make([]byte, config.StackSize)
+						stack = stack[:runtime.Stack(stack, !config.DisableStackAll)]
+					}
+
+					if config.LogErrorFunc != nil {
+						err = config.LogErrorFunc(c, err, stack)
+					} else if !config.DisablePrintStack {
+						c.Logger().Errorf("[PANIC RECOVER] %v %s\n", err, stack)
+					}
+
+					if err != nil && !config.DisableErrorHandler {
+						c.Error(err)
+					} else {
+						returnErr = err
+					}
+				}
+			}()
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := Recover()(func(c echo.Context) error {
+		panic("test")
+	})
+
+	err := h(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, buf.String(), "PANIC RECOVER")
+	assert.Contains(t, buf.String(), "test")
+	assert.Contains(t, buf.String(), "goroutine")
+}
+
+func TestRecoverThroughServeHTTP(t *testing.T) {
+	e := echo.New()
+	e.Logger.SetOutput(new(bytes.Buffer))
+	e.Use(Recover())
+	e.GET("/", func(c echo.Context) error {
+		panic(errors.New("boom"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestRecoverErrAbortHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := Recover()(func(c echo.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h(c)
+	})
+}
+
+func TestRecoverWithConfig_LogErrorFunc(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+
+	var logged error
+	var loggedStack []byte
+	testCases := []struct {
+		name          string
+		config        RecoverConfig
+		expectErr     error
+		expectCode    int
+		expectHandled bool
+	}{
+		{
+			name: "error is passed to error handler",
+			config: RecoverConfig{
+				LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+					logged, loggedStack = err, stack
+					return echo.ErrServiceUnavailable
+				},
+			},
+			expectCode: http.StatusServiceUnavailable,
+		},
+		{
+			name: "nil error means handled",
+			config: RecoverConfig{
+				LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+					logged, loggedStack = err, stack
+					return nil
+				},
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name: "error handler disabled returns error",
+			config: RecoverConfig{
+				DisableErrorHandler: true,
+				LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+					logged, loggedStack = err, stack
+					return err
+				},
+			},
+			expectErr:  errors.New("panic!"),
+			expectCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logged, loggedStack = nil, nil
+			buf.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			h := RecoverWithConfig(tc.config)(func(c echo.Context) error {
+				panic("panic!")
+			})
+
+			err := h(c)
+			if tc.expectErr != nil {
+				assert.EqualError(t, err, tc.expectErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.EqualError(t, logged, "panic!")
+			assert.NotEmpty(t, loggedStack)
+			assert.Empty(t, buf.String())
+		})
+	}
+}
+
+func TestRecoverWithConfig_StackSize(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var stack []byte
+	h := RecoverWithConfig(RecoverConfig{
+		StackSize: 64,
+		LogErrorFunc: func(c echo.Context, err error, s []byte) error {
+			stack = s
+			return err
+		},
+	})(func(c echo.Context) error {
+		panic("test")
+	})
+
+	assert.NoError(t, h(c))
+	assert.Len(t, stack, 64)
+}
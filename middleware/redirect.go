@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// RedirectConfig defines the config for Redirect middleware.
+	RedirectConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Status code to be used when redirecting the request.
+		// One of 301, 302, 307 or 308.
+		// Optional. Default value http.StatusMovedPermanently.
+		Code int
+	}
+
+	// redirectLogic represents a function that given a scheme, host and uri
+	// can both: 1) determine if redirect is needed (will set ok accordingly) and
+	// 2) return the appropriate redirect url.
+	redirectLogic func(scheme, host, uri string) (ok bool, url string)
+)
+
+const www = "www."
+
+var (
+	// DefaultRedirectConfig is the default Redirect middleware config.
+	DefaultRedirectConfig = RedirectConfig{
+		Skipper: DefaultSkipper,
+		Code:    http.StatusMovedPermanently,
+	}
+)
+
+// HTTPSRedirect redirects http requests to https.
+// For example, http://example.com will be redirect to https://example.com.
+//
+// Usage `Echo#Pre(HTTPSRedirect())`
+func HTTPSRedirect() echo.MiddlewareFunc {
+	return HTTPSRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSRedirectWithConfig returns an HTTPSRedirect middleware with config.
+// See `HTTPSRedirect()`.
+func HTTPSRedirectWithConfig(config RedirectConfig) echo.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (bool, string) {
+		if scheme != "https" {
+			return true, "https://" + host + uri
+		}
+		return false, ""
+	})
+}
+
+// HTTPSWWWRedirect redirects http and non www requests to https and www.
+// For example, http://example.com will be redirect to https://www.example.com.
+//
+// Usage `Echo#Pre(HTTPSWWWRedirect())`
+func HTTPSWWWRedirect() echo.MiddlewareFunc {
+	return HTTPSWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSWWWRedirectWithConfig returns an HTTPSWWWRedirect middleware with config.
+// See `HTTPSWWWRedirect()`.
+func HTTPSWWWRedirectWithConfig(config RedirectConfig) echo.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (bool, string) {
+		if scheme != "https" || !strings.HasPrefix(host, www) {
+			return true, "https://" + www + strings.TrimPrefix(host, www) + uri
+		}
+		return false, ""
+	})
+}
+
+// HTTPSNonWWWRedirect redirects http and www requests to https and non www.
+// For example, http://www.example.com will be redirect to https://example.com.
+//
+// Usage `Echo#Pre(HTTPSNonWWWRedirect())`
+func HTTPSNonWWWRedirect() echo.MiddlewareFunc {
+	return HTTPSNonWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSNonWWWRedirectWithConfig returns an HTTPSNonWWWRedirect middleware with config.
+// See `HTTPSNonWWWRedirect()`.
+func HTTPSNonWWWRedirectWithConfig(config RedirectConfig) echo.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (bool, string) {
+		if scheme != "https" || strings.HasPrefix(host, www) {
+			return true, "https://" + strings.TrimPrefix(host, www) + uri
+		}
+		return false, ""
+	})
+}
+
+// WWWRedirect redirects non www requests to www.
+// For example, http://example.com will be redirect to http://www.example.com.
+//
+// Usage `Echo#Pre(WWWRedirect())`
+func WWWRedirect() echo.MiddlewareFunc {
+	return WWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// WWWRedirectWithConfig returns a WWWRedirect middleware with config.
+// See `WWWRedirect()`.
+func WWWRedirectWithConfig(config RedirectConfig) echo.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (bool, string) {
+		if !strings.HasPrefix(host, www) {
+			return true, scheme + "://" + www + host + uri
+		}
+		return false, ""
+	})
+}
+
+// NonWWWRedirect redirects www requests to non www.
+// For example, http://www.example.com will be redirect to http://example.com.
+//
+// Usage `Echo#Pre(NonWWWRedirect())`
+func NonWWWRedirect() echo.MiddlewareFunc {
+	return NonWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// NonWWWRedirectWithConfig returns a NonWWWRedirect middleware with config.
+// See `NonWWWRedirect()`.
+func NonWWWRedirectWithConfig(config RedirectConfig) echo.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (bool, string) {
+		if strings.HasPrefix(host, www) {
+			return true, scheme + "://" + host[len(www):] + uri
+		}
+		return false, ""
+	})
+}
+
+func redirect(config RedirectConfig, cb redirectLogic) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRedirectConfig.Skipper
+	}
+	if config.Code == 0 {
+		config.Code = DefaultRedirectConfig.Code
+	}
+	switch config.Code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		panic("echo: invalid redirect code")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			// The scheme honours X-Forwarded-Proto when behind a TLS terminating proxy.
+			req, scheme := c.Request(), c.Scheme()
+			uri := req.RequestURI
+			if uri == "" {
+				uri = req.URL.RequestURI()
+			}
+			if ok, url := cb(scheme, req.Host, sanitizeURI(uri)); ok {
+				return c.Redirect(config.Code, url)
+			}
+			return next(c)
+		}
+	}
+}
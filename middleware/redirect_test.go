@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+type middlewareGenerator func() echo.MiddlewareFunc
+
+func TestRedirect(t *testing.T) {
+	testCases := []struct {
+		name           string
+		whenMiddleware middlewareGenerator
+		whenHost       string
+		whenURI        string
+		whenHeader     http.Header
+		expectLocation string
+	}{
+		{name: "https", whenMiddleware: HTTPSRedirect, whenHost: "example.com", whenURI: "/a?b=c", expectLocation: "https://example.com/a?b=c"},
+		{
+			name:           "https behind proxy",
+			whenMiddleware: HTTPSRedirect,
+			whenHost:       "example.com",
+			whenHeader:     http.Header{echo.HeaderXForwardedProto: []string{"https"}},
+		},
+		{name: "https www", whenMiddleware: HTTPSWWWRedirect, whenHost: "example.com", expectLocation: "https://www.example.com/"},
+		{name: "https www from www", whenMiddleware: HTTPSWWWRedirect, whenHost: "www.example.com", expectLocation: "https://www.example.com/"},
+		{
+			name:           "https www from https",
+			whenMiddleware: HTTPSWWWRedirect,
+			whenHost:       "example.com",
+			whenHeader:     http.Header{echo.HeaderXForwardedProto: []string{"https"}},
+			expectLocation: "https://www.example.com/",
+		},
+		{
+			name:           "https www satisfied",
+			whenMiddleware: HTTPSWWWRedirect,
+			whenHost:       "www.example.com",
+			whenHeader:     http.Header{echo.HeaderXForwardedProto: []string{"https"}},
+		},
+		{name: "https non-www", whenMiddleware: HTTPSNonWWWRedirect, whenHost: "www.example.com", expectLocation: "https://example.com/"},
+		{name: "https non-www from non-www", whenMiddleware: HTTPSNonWWWRedirect, whenHost: "example.com", expectLocation: "https://example.com/"},
+		{
+			name:           "https non-www satisfied",
+			whenMiddleware: HTTPSNonWWWRedirect,
+			whenHost:       "example.com",
+			whenHeader:     http.Header{echo.HeaderXForwardedProto: []string{"https"}},
+		},
+		{name: "www", whenMiddleware: WWWRedirect, whenHost: "example.com:8080", expectLocation: "http://www.example.com:8080/"},
+		{name: "www satisfied", whenMiddleware: WWWRedirect, whenHost: "www.example.com"},
+		{
+			name:           "www keeps scheme",
+			whenMiddleware: WWWRedirect,
+			whenHost:       "example.com",
+			whenHeader:     http.Header{echo.HeaderXForwardedProto: []string{"https"}},
+			expectLocation: "https://www.example.com/",
+		},
+		{name: "non-www", whenMiddleware: NonWWWRedirect, whenHost: "www.example.com", whenURI: "/a", expectLocation: "http://example.com/a"},
+		{name: "non-www satisfied", whenMiddleware: NonWWWRedirect, whenHost: "example.com"},
+		{name: "open redirect", whenMiddleware: HTTPSRedirect, whenHost: "example.com", whenURI: "//evil.com", expectLocation: "https://example.com/evil.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.Pre(tc.whenMiddleware())
+			e.GET("/", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			uri := "/"
+			if tc.whenURI != "" {
+				uri = tc.whenURI
+			}
+			req := httptest.NewRequest(http.MethodGet, uri, nil)
+			req.Host = tc.whenHost
+			for k, v := range tc.whenHeader {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if tc.expectLocation == "" {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				return
+			}
+			assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+			assert.Equal(t, tc.expectLocation, rec.Header().Get(echo.HeaderLocation))
+		})
+	}
+}
+
+func TestRedirectWithConfig_code(t *testing.T) {
+	e := echo.New()
+	mw := HTTPSRedirectWithConfig(RedirectConfig{Code: http.StatusPermanentRedirect})
+	h := mw(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.Host = "example.com"
+	rec := httptest.NewRecorder()
+	assert.NoError(t, h(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+	assert.Equal(t, "https://example.com/form", rec.Header().Get(echo.HeaderLocation))
+
+	assert.Panics(t, func() {
+		NonWWWRedirectWithConfig(RedirectConfig{Code: http.StatusOK})
+	})
+}
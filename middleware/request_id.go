@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// RequestIDConfig defines the config for RequestID middleware.
+	RequestIDConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Generator defines a function to generate an ID.
+		// Optional. Default value RandomHexGenerator(16).
+		Generator func() string
+
+		// Validator reports whether an incoming request ID may be reused. IDs
+		// that fail validation are replaced by a generated one.
+		// Optional. Default value accepts printable ASCII without spaces.
+		Validator func(id string) bool
+
+		// MaxLength caps the length of an incoming request ID. Longer IDs are
+		// replaced by a generated one.
+		// Optional. Default value 128.
+		MaxLength int
+
+		// RequestIDHandler defines a function which is executed for a request id.
+		RequestIDHandler func(echo.Context, string)
+
+		// TargetHeader defines what header to look for to populate the id.
+		// Optional. Default value `echo.HeaderXRequestID`.
+		TargetHeader string
+
+		// ContextKey is the key under which the ID is stored in the context.
+		// Optional. Default value RequestIDContextKey.
+		ContextKey string
+	}
+
+	requestIDContextKey struct{}
+)
+
+// RequestIDContextKey is the default key the request ID is stored under in
+// `echo.Context`.
+const RequestIDContextKey = "request_id"
+
+var (
+	// DefaultRequestIDConfig is the default RequestID middleware config.
+	DefaultRequestIDConfig = RequestIDConfig{
+		Skipper:      DefaultSkipper,
+		Generator:    RandomHexGenerator(16),
+		Validator:    isValidRequestID,
+		MaxLength:    128,
+		TargetHeader: echo.HeaderXRequestID,
+		ContextKey:   RequestIDContextKey,
+	}
+)
+
+// RequestID returns a X-Request-ID middleware.
+func RequestID() echo.MiddlewareFunc {
+	return RequestIDWithConfig(DefaultRequestIDConfig)
+}
+
+// RequestIDWithConfig returns a X-Request-ID middleware with config.
+func RequestIDWithConfig(config RequestIDConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequestIDConfig.Skipper
+	}
+	if config.Generator == nil {
+		config.Generator = DefaultRequestIDConfig.Generator
+	}
+	if config.Validator == nil {
+		config.Validator = DefaultRequestIDConfig.Validator
+	}
+	if config.MaxLength == 0 {
+		config.MaxLength = DefaultRequestIDConfig.MaxLength
+	}
+	if config.TargetHeader == "" {
+		config.TargetHeader = DefaultRequestIDConfig.TargetHeader
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultRequestIDConfig.ContextKey
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			rid := req.Header.Get(config.TargetHeader)
+			if rid == "" || len(rid) > config.MaxLength || !config.Validator(rid) {
+				rid = config.Generator()
+			}
+			res.Header().Set(config.TargetHeader, rid)
+			c.Set(config.ContextKey, rid)
+			c.SetRequest(req.WithContext(context.WithValue(req.Context(), requestIDContextKey{}, rid)))
+			if config.RequestIDHandler != nil {
+				config.RequestIDHandler(c, rid)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// GetRequestID returns the request ID assigned by the RequestID middleware,
+// whatever its ContextKey and TargetHeader are. It falls back to the default
+// context key and response header, e.g. for IDs set by other means.
+func GetRequestID(c echo.Context) string {
+	if rid := RequestIDFromContext(c.Request().Context()); rid != "" {
+		return rid
+	}
+	if rid, ok := c.Get(RequestIDContextKey).(string); ok {
+		return rid
+	}
+	return c.Response().Header().Get(echo.HeaderXRequestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, which is derived
+// from the request the RequestID middleware handled. Use it to propagate the ID
+// to outbound calls made with that context.
+func RequestIDFromContext(ctx context.Context) string {
+	rid, _ := ctx.Value(requestIDContextKey{}).(string)
+	return rid
+}
+
+// RandomHexGenerator returns a generator of random IDs made of n random bytes
+// encoded as 2*n hex characters.
+func RandomHexGenerator(n int) func() string {
+	return func() string {
+		return hex.EncodeToString(randomBytes(n))
+	}
+}
+
+// crockford is the Crockford base32 alphabet which sorts the same way as the
+// values it encodes.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// SortableIDGenerator returns a generator of ULID-like IDs: 26 characters
+// holding a 48 bit millisecond timestamp followed by 80 random bits. IDs
+// generated in different milliseconds sort lexically by creation time.
+func SortableIDGenerator() func() string {
+	return func() string {
+		var id [16]byte
+		binary.BigEndian.PutUint64(id[:8], uint64(time.Now().UnixNano()/int64(time.Millisecond))<<16)
+		copy(id[6:], randomBytes(10))
+
+		// 128 bits are encoded as 26 characters of 5 bits with the 2 leading
+		// bits of the first character always zero.
+		var dst [26]byte
+		hi := binary.BigEndian.Uint64(id[:8])
+		lo := binary.BigEndian.Uint64(id[8:])
+		for i := 25; i >= 0; i-- {
+			dst[i] = crockford[lo&0x1f]
+			lo = lo>>5 | hi<<59
+			hi >>= 5
+		}
+		return string(dst[:])
+	}
+}
+
+func isValidRequestID(id string) bool {
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var fromStore, fromCtx string
+	h := RequestID()(func(c echo.Context) error {
+		fromStore = GetRequestID(c)
+		fromCtx = RequestIDFromContext(c.Request().Context())
+		return c.String(http.StatusOK, "test")
+	})
+
+	assert.NoError(t, h(c))
+	rid := rec.Header().Get(echo.HeaderXRequestID)
+	assert.Len(t, rid, 32)
+	assert.Equal(t, rid, fromStore)
+	assert.Equal(t, rid, fromCtx)
+}
+
+func TestRequestID_IDFromRequest(t *testing.T) {
+	e := echo.New()
+	testCases := []struct {
+		name      string
+		given     string
+		expectNew bool
+	}{
+		{name: "valid id is reused", given: "req-1234"},
+		{name: "too long id is replaced", given: strings.Repeat("a", 129), expectNew: true},
+		{name: "id with spaces is replaced", given: "req 1234", expectNew: true},
+		{name: "id with control characters is replaced", given: "req\x001234", expectNew: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, tc.given)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			h := RequestIDWithConfig(RequestIDConfig{
+				Generator: func() string { return "generated" },
+			})(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			assert.NoError(t, h(c))
+			if tc.expectNew {
+				assert.Equal(t, "generated", rec.Header().Get(echo.HeaderXRequestID))
+			} else {
+				assert.Equal(t, tc.given, rec.Header().Get(echo.HeaderXRequestID))
+			}
+		})
+	}
+}
+
+func TestRequestIDWithConfig_TargetHeaderAndHandler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "abc")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	called := ""
+	h := RequestIDWithConfig(RequestIDConfig{
+		TargetHeader: "X-Correlation-ID",
+		ContextKey:   "cid",
+		RequestIDHandler: func(c echo.Context, rid string) {
+			called = rid
+		},
+	})(func(c echo.Context) error {
+		assert.Equal(t, "abc", c.Get("cid"))
+		assert.Equal(t, "abc", GetRequestID(c))
+		return nil
+	})
+
+	assert.NoError(t, h(c))
+	assert.Equal(t, "abc", called)
+	assert.Equal(t, "abc", rec.Header().Get("X-Correlation-ID"))
+	assert.Empty(t, rec.Header().Get(echo.HeaderXRequestID))
+}
+
+func TestSortableIDGenerator(t *testing.T) {
+	gen := SortableIDGenerator()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, gen())
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	assert.True(t, sort.StringsAreSorted(ids), ids)
+	for _, id := range ids {
+		assert.Len(t, id, 26)
+		assert.Equal(t, -1, strings.IndexFunc(id, func(r rune) bool {
+			return !strings.ContainsRune(crockford, r)
+		}))
+	}
+	assert.NotEqual(t, ids[0][10:], ids[1][10:])
+}
@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// RequestLoggerConfig defines the config for RequestLogger middleware. Instead of
+	// formatting a log line itself, the middleware collects the values selected with
+	// the Log* fields and hands them over to LogValuesFunc, which makes it easy to
+	// feed structured logging libraries.
+	RequestLoggerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// BeforeNextFunc defines a function that is called before next middleware or
+		// handler is called in chain.
+		BeforeNextFunc func(c echo.Context)
+
+		// LogValuesFunc defines a function that is called with values extracted by
+		// logger from request/response.
+		// Mandatory.
+		LogValuesFunc func(c echo.Context, v RequestLoggerValues) error
+
+		// HandleError instructs logger to call global error handler when next
+		// middleware/handler returns an error. This is useful when the status
+		// code decided by the error handler should be logged.
+		HandleError bool
+
+		// LogLatency instructs logger to record duration it took to execute rest of
+		// the handler chain.
+		LogLatency bool
+		// LogProtocol instructs logger to extract request protocol (i.e. `HTTP/1.1`).
+		LogProtocol bool
+		// LogRemoteIP instructs logger to extract request remote IP. See `echo.Context.RealIP()`.
+		LogRemoteIP bool
+		// LogHost instructs logger to extract request host value (i.e. `example.com`).
+		LogHost bool
+		// LogMethod instructs logger to extract request method value (i.e. `GET`).
+		LogMethod bool
+		// LogURI instructs logger to extract request URI (i.e. `/list?lang=en&page=1`).
+		LogURI bool
+		// LogURIPath instructs logger to extract request URI path part (i.e. `/list`).
+		LogURIPath bool
+		// LogRoutePath instructs logger to extract route path part to which request
+		// was matched to (i.e. `/user/:id`).
+		LogRoutePath bool
+		// LogRequestID instructs logger to extract request ID from request
+		// `X-Request-ID` header or response if request did not have value.
+		LogRequestID bool
+		// LogReferer instructs logger to extract request referer values.
+		LogReferer bool
+		// LogUserAgent instructs logger to extract request user agent values.
+		LogUserAgent bool
+		// LogStatus instructs logger to extract response status code. If handler
+		// chain returns an echo.HTTPError, the status code is extracted from the
+		// echo.HTTPError returned.
+		LogStatus bool
+		// LogError instructs logger to extract error returned from executed handler chain.
+		LogError bool
+		// LogContentLength instructs logger to extract content length header value.
+		LogContentLength bool
+		// LogResponseSize instructs logger to extract response content length value.
+		LogResponseSize bool
+		// LogHeaders instructs logger to extract given list of headers from request.
+		LogHeaders []string
+		// LogQueryParams instructs logger to extract given list of query parameters
+		// from request URI.
+		LogQueryParams []string
+	}
+
+	// RequestLoggerValues contains extracted values from logger.
+	RequestLoggerValues struct {
+		StartTime     time.Time
+		Latency       time.Duration
+		Protocol      string
+		RemoteIP      string
+		Host          string
+		Method        string
+		URI           string
+		URIPath       string
+		RoutePath     string
+		RequestID     string
+		Referer       string
+		UserAgent     string
+		Status        int
+		Error         error
+		ContentLength string
+		ResponseSize  int64
+		Headers       map[string][]string
+		QueryParams   map[string][]string
+	}
+)
+
+// RequestLoggerWithConfig returns a RequestLogger middleware with config.
+func RequestLoggerWithConfig(config RequestLoggerConfig) echo.MiddlewareFunc {
+	mw, err := config.ToMiddleware()
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+// ToMiddleware converts RequestLoggerConfig into middleware or returns an error
+// for invalid configuration.
+func (config RequestLoggerConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	if config.LogValuesFunc == nil {
+		return nil, errors.New("missing LogValuesFunc callback function for request logger middleware")
+	}
+
+	headers := make([]string, len(config.LogHeaders))
+	for i, h := range config.LogHeaders {
+		headers[i] = http.CanonicalHeaderKey(h)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			start := time.Now()
+
+			if config.BeforeNextFunc != nil {
+				config.BeforeNextFunc(c)
+			}
+			err := next(c)
+			if err != nil && config.HandleError {
+				c.Error(err)
+			}
+
+			v := RequestLoggerValues{
+				StartTime: start,
+			}
+			if config.LogLatency {
+				v.Latency = time.Since(start)
+			}
+			if config.LogProtocol {
+				v.Protocol = req.Proto
+			}
+			if config.LogRemoteIP {
+				v.RemoteIP = c.RealIP()
+			}
+			if config.LogHost {
+				v.Host = req.Host
+			}
+			if config.LogMethod {
+				v.Method = req.Method
+			}
+			if config.LogURI {
+				v.URI = req.RequestURI
+			}
+			if config.LogURIPath {
+				p := req.URL.Path
+				if p == "" {
+					p = "/"
+				}
+				v.URIPath = p
+			}
+			if config.LogRoutePath {
+				v.RoutePath = c.Path()
+			}
+			if config.LogRequestID {
+				id := req.Header.Get(echo.HeaderXRequestID)
+				if id == "" {
+					id = res.Header().Get(echo.HeaderXRequestID)
+				}
+				v.RequestID = id
+			}
+			if config.LogReferer {
+				v.Referer = req.Referer()
+			}
+			if config.LogUserAgent {
+				v.UserAgent = req.UserAgent()
+			}
+			if config.LogStatus {
+				v.Status = res.Status
+				if err != nil && !config.HandleError {
+					// the error handler has not been run yet so the response status
+					// does not reflect the error
+					if he, ok := err.(*echo.HTTPError); ok {
+						v.Status = he.Code
+					} else {
+						v.Status = http.StatusInternalServerError
+					}
+				}
+			}
+			if config.LogError && err != nil {
+				v.Error = err
+			}
+			if config.LogContentLength {
+				v.ContentLength = req.Header.Get(echo.HeaderContentLength)
+			}
+			if config.LogResponseSize {
+				v.ResponseSize = res.Size
+			}
+			if len(headers) > 0 {
+				v.Headers = map[string][]string{}
+				for _, h := range headers {
+					if values, ok := req.Header[h]; ok {
+						v.Headers[h] = values
+					}
+				}
+			}
+			if len(config.LogQueryParams) > 0 {
+				v.QueryParams = map[string][]string{}
+				for _, p := range config.LogQueryParams {
+					if values, ok := c.QueryParams()[p]; ok {
+						v.QueryParams[p] = values
+					}
+				}
+			}
+
+			if errOnLog := config.LogValuesFunc(c, v); errOnLog != nil {
+				return errOnLog
+			}
+
+			// in case of HandleError=true we are returning the error that we already
+			// have handled with global error handler. This is deliberate as this error
+			// could be useful for upstream middlewares and default global error handler
+			// will ignore that error when it bubbles up in place where Echo router is.
+			return err
+		}
+	}, nil
+}
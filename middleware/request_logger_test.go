@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLoggerWithConfig_missingOnLogValuesPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		RequestLoggerWithConfig(RequestLoggerConfig{})
+	})
+}
+
+func TestRequestLogger_allFields(t *testing.T) {
+	e := echo.New()
+
+	var expect RequestLoggerValues
+	e.Use(RequestLoggerWithConfig(RequestLoggerConfig{
+		LogLatency:       true,
+		LogProtocol:      true,
+		LogRemoteIP:      true,
+		LogHost:          true,
+		LogMethod:        true,
+		LogURI:           true,
+		LogURIPath:       true,
+		LogRoutePath:     true,
+		LogRequestID:     true,
+		LogReferer:       true,
+		LogUserAgent:     true,
+		LogStatus:        true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		LogHeaders:       []string{"accept-encoding"},
+		LogQueryParams:   []string{"lang"},
+		LogValuesFunc: func(c echo.Context, values RequestLoggerValues) error {
+			expect = values
+			return nil
+		},
+	}))
+	e.GET("/test", func(c echo.Context) error {
+		return c.String(http.StatusTeapot, "OK")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test?lang=en", nil)
+	req.Header.Set(echo.HeaderXRealIP, "8.8.8.8")
+	req.Header.Set(echo.HeaderXRequestID, "MY_ID")
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	req.Header.Set("Referer", "https://echo.labstack.com/")
+	req.Header.Set("User-Agent", "curl/7.68.0")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, "HTTP/1.1", expect.Protocol)
+	assert.Equal(t, "8.8.8.8", expect.RemoteIP)
+	assert.Equal(t, "example.com", expect.Host)
+	assert.Equal(t, http.MethodGet, expect.Method)
+	assert.Equal(t, "/test?lang=en", expect.URI)
+	assert.Equal(t, "/test", expect.URIPath)
+	assert.Equal(t, "/test", expect.RoutePath)
+	assert.Equal(t, "MY_ID", expect.RequestID)
+	assert.Equal(t, "https://echo.labstack.com/", expect.Referer)
+	assert.Equal(t, "curl/7.68.0", expect.UserAgent)
+	assert.Equal(t, http.StatusTeapot, expect.Status)
+	assert.Equal(t, int64(2), expect.ResponseSize)
+	assert.Equal(t, map[string][]string{"Accept-Encoding": {"gzip"}}, expect.Headers)
+	assert.Equal(t, map[string][]string{"lang": {"en"}}, expect.QueryParams)
+	assert.False(t, expect.StartTime.IsZero())
+}
+
+func TestRequestLogger_statusFromError(t *testing.T) {
+	e := echo.New()
+
+	var expect RequestLoggerValues
+	mw := RequestLoggerWithConfig(RequestLoggerConfig{
+		LogStatus: true,
+		LogError:  true,
+		LogValuesFunc: func(c echo.Context, values RequestLoggerValues) error {
+			expect = values
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := mw(func(c echo.Context) error {
+		return echo.ErrBadRequest
+	})(c)
+
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, http.StatusBadRequest, expect.Status)
+	assert.Equal(t, echo.ErrBadRequest, expect.Error)
+}
+
+func TestRequestLogger_HandleError(t *testing.T) {
+	e := echo.New()
+
+	var expect RequestLoggerValues
+	mw := RequestLoggerWithConfig(RequestLoggerConfig{
+		HandleError: true,
+		LogStatus:   true,
+		LogValuesFunc: func(c echo.Context, values RequestLoggerValues) error {
+			expect = values
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	err := mw(func(c echo.Context) error {
+		return errors.New("nope")
+	})(c)
+
+	assert.EqualError(t, err, "nope")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, http.StatusInternalServerError, expect.Status)
+}
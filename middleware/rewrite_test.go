@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewrite(t *testing.T) {
+	testCases := []struct {
+		whenURL     string
+		expectPath  string
+		expectRaw   string
+		expectQuery string
+	}{
+		{whenURL: "/api/users", expectPath: "/users"},
+		{whenURL: "/js/main.js", expectPath: "/public/javascripts/main.js"},
+		{whenURL: "/old", expectPath: "/new"},
+		{whenURL: "/old/1/2", expectPath: "/new/1/2"},
+		{whenURL: "/users/jack/orders/1", expectPath: "/user/jack/order/1"},
+		{whenURL: "/api/new%20users?limit=10", expectPath: "/new users", expectQuery: "limit=10"},
+		{
+			whenURL:    "/user/jill/order/T%2F1%2Fabc%252F",
+			expectPath: "/user/jill/order/T/1/abc%2F",
+			expectRaw:  "/user/jill/order/T%2F1%2Fabc%252F",
+		},
+		{whenURL: "/unmatched", expectPath: "/unmatched"},
+	}
+
+	e := echo.New()
+	e.Pre(Rewrite(map[string]string{
+		"/old":              "/new",
+		"/old/*":            "/new/$1",
+		"/api/*":            "/$1",
+		"/js/*":             "/public/javascripts/$1",
+		"/users/*/orders/*": "/user/$1/order/$2",
+	}))
+
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.whenURL, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectPath, req.URL.Path)
+			assert.Equal(t, tc.expectRaw, req.URL.RawPath)
+			assert.Equal(t, tc.expectQuery, req.URL.RawQuery)
+		})
+	}
+}
+
+func TestRewriteWithConfig_regexRules(t *testing.T) {
+	e := echo.New()
+	e.Pre(RewriteWithConfig(RewriteConfig{
+		Rules: map[string]string{
+			"^/a/*": "/v1/$1",
+		},
+		RegexRules: map[*regexp.Regexp]string{
+			regexp.MustCompile("^/x/(.*?)/(.*)"): "/$2/$1",
+		},
+	}))
+	e.GET("/v2/d", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Request().URL.Path)
+	})
+
+	// the router matches the rewritten path
+	req := httptest.NewRequest(http.MethodGet, "/x/d/v2", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/v2/d", rec.Body.String())
+
+	for whenURL, expectPath := range map[string]string{
+		"/a/b":   "/v1/b",
+		"/x/1/2": "/2/1",
+	} {
+		req = httptest.NewRequest(http.MethodGet, whenURL, nil)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, expectPath, req.URL.Path)
+	}
+
+	assert.Panics(t, func() {
+		RewriteWithConfig(RewriteConfig{})
+	})
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecure(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	// Default
+	assert.NoError(t, Secure()(h)(c))
+	assert.Equal(t, "1; mode=block", rec.Header().Get(echo.HeaderXXSSProtection))
+	assert.Equal(t, "nosniff", rec.Header().Get(echo.HeaderXContentTypeOptions))
+	assert.Equal(t, "SAMEORIGIN", rec.Header().Get(echo.HeaderXFrameOptions))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderStrictTransportSecurity))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderReferrerPolicy))
+
+	// Custom
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, SecureWithConfig(SecureConfig{
+		XSSProtection:         "",
+		ContentTypeNosniff:    "",
+		XFrameOptions:         "",
+		HSTSMaxAge:            3600,
+		ContentSecurityPolicy: "default-src 'self'",
+		ReferrerPolicy:        "origin",
+	})(h)(c))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderXXSSProtection))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderXContentTypeOptions))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderXFrameOptions))
+	assert.Equal(t, "max-age=3600; includeSubdomains", rec.Header().Get(echo.HeaderStrictTransportSecurity))
+	assert.Equal(t, "default-src 'self'", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderContentSecurityPolicyReportOnly))
+	assert.Equal(t, "origin", rec.Header().Get(echo.HeaderReferrerPolicy))
+
+	// CSP Report Only
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, SecureWithConfig(SecureConfig{
+		ContentSecurityPolicy: "default-src 'self'",
+		CSPReportOnly:         true,
+	})(h)(c))
+	assert.Equal(t, "default-src 'self'", rec.Header().Get(echo.HeaderContentSecurityPolicyReportOnly))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+
+	// HSTS preload, no subdomains
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, SecureWithConfig(SecureConfig{
+		HSTSMaxAge:            3600,
+		HSTSExcludeSubdomains: true,
+		HSTSPreloadEnabled:    true,
+	})(h)(c))
+	assert.Equal(t, "max-age=3600; preload", rec.Header().Get(echo.HeaderStrictTransportSecurity))
+}
+
+func TestSecure_HSTSOnlyOverTLS(t *testing.T) {
+	testCases := []struct {
+		name          string
+		whenTLS       bool
+		whenProto     string
+		trustProto    bool
+		expectHSTSSet bool
+	}{
+		{name: "plain http", expectHSTSSet: false},
+		{name: "tls", whenTLS: true, expectHSTSSet: true},
+		{name: "untrusted forwarded proto", whenProto: "https", expectHSTSSet: false},
+		{name: "trusted forwarded proto", whenProto: "https", trustProto: true, expectHSTSSet: true},
+		{name: "trusted forwarded proto http", whenProto: "http", trustProto: true, expectHSTSSet: false},
+	}
+
+	e := echo.New()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.whenTLS {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tc.whenProto != "" {
+				req.Header.Set(echo.HeaderXForwardedProto, tc.whenProto)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mw := SecureWithConfig(SecureConfig{HSTSMaxAge: 60, TrustForwardedProto: tc.trustProto})
+			assert.NoError(t, mw(func(c echo.Context) error { return nil })(c))
+			assert.Equal(t, tc.expectHSTSSet, rec.Header().Get(echo.HeaderStrictTransportSecurity) != "")
+		})
+	}
+}
+
+func TestSecure_CSPNonce(t *testing.T) {
+	e := echo.New()
+	mw := SecureWithConfig(SecureConfig{
+		ContentSecurityPolicy: "script-src 'self' 'nonce-${nonce}'; style-src 'nonce-${nonce}'",
+	})
+
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		var nonce string
+		assert.NoError(t, mw(func(c echo.Context) error {
+			nonce = CSPNonce(c)
+			return nil
+		})(c))
+
+		assert.Len(t, nonce, 24)
+		assert.Equal(t,
+			"script-src 'self' 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'",
+			rec.Header().Get(echo.HeaderContentSecurityPolicy))
+		nonces = append(nonces, nonce)
+	}
+	assert.NotEqual(t, nonces[0], nonces[1])
+}
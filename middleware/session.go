@@ -0,0 +1,570 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// SessionsConfig defines the config for Sessions middleware.
+	SessionsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Store keeps the session data between requests.
+		// Required.
+		Store SessionStore
+
+		// IdleTimeout ends sessions which were not used for the given duration.
+		// Optional. Default value 30 minutes.
+		IdleTimeout time.Duration
+
+		// AbsoluteTimeout ends sessions the given duration after they were
+		// created, however active they are.
+		// Optional. Default value 12 hours.
+		AbsoluteTimeout time.Duration
+
+		// Name of the session cookie.
+		// Optional. Default value "session".
+		CookieName string
+
+		// Domain of the session cookie.
+		// Optional. Default value none.
+		CookieDomain string
+
+		// Path of the session cookie.
+		// Optional. Default value "/".
+		CookiePath string
+
+		// Indicates if the session cookie is secure.
+		// Optional. Default value false.
+		CookieSecure bool
+
+		// Indicates if the session cookie is HTTP only.
+		// Optional. Default value true.
+		CookieHTTPOnly *bool
+
+		// Indicates SameSite mode of the session cookie. `http.SameSiteNoneMode`
+		// forces the cookie to be secure as browsers reject it otherwise.
+		// Optional. Default value http.SameSiteLaxMode.
+		CookieSameSite http.SameSite
+
+		// TimeFunc returns the current time to check the timeouts against.
+		// Optional. Default value time.Now.
+		TimeFunc func() time.Time
+	}
+
+	// SessionStore is the interface to be implemented by session stores.
+	SessionStore interface {
+		// Load returns the session identified by the cookie value or nil if
+		// there is no such session.
+		Load(value string) (*Session, error)
+
+		// Save persists the session and returns the cookie value identifying it.
+		Save(s *Session) (string, error)
+
+		// Delete removes the session from the store.
+		Delete(s *Session) error
+	}
+
+	// Session holds the values kept for a client between requests. It is
+	// obtained with `GetSession()` and saved automatically when the response
+	// is written.
+	Session struct {
+		// ID identifies the session. It changes on `RegenerateSession()`.
+		ID string
+		// Values holds the session data.
+		Values map[string]interface{}
+		// CreatedAt is the time the session was started.
+		CreatedAt time.Time
+		// LastAccessedAt is the time the session was last used.
+		LastAccessedAt time.Time
+
+		flashes   []string
+		isNew     bool
+		dirty     bool // needs to be saved
+		destroyed bool
+		config    *SessionsConfig
+	}
+
+	// CookieSessionStore keeps the whole session in a signed cookie. The
+	// values are JSON encoded, so they come back as the types encoding/json
+	// decodes into interface{}, and readable by the client, so it must not
+	// hold secrets. Sessions can not be revoked: a copy of a cookie stays
+	// valid until it expires, even after `DestroySession()` or
+	// `RegenerateSession()`. Use a server side store, e.g. MemorySessionStore,
+	// when logouts must invalidate sessions.
+	CookieSessionStore struct {
+		keys [][]byte
+	}
+
+	// MemorySessionStore keeps sessions in memory, which is suitable for a
+	// single server. Sessions unused for TTL are evicted.
+	MemorySessionStore struct {
+		mutex    sync.Mutex
+		sessions map[string]*Session
+		ttl      time.Duration
+
+		lastCleanup time.Time
+		timeNow     func() time.Time
+	}
+
+	// sessionData is the serialized form of a session.
+	sessionData struct {
+		ID             string                 `json:"id"`
+		Values         map[string]interface{} `json:"v,omitempty"`
+		Flashes        []string               `json:"f,omitempty"`
+		CreatedAt      int64                  `json:"c"`
+		LastAccessedAt int64                  `json:"a"`
+	}
+)
+
+const (
+	// SessionContextKey is the key the Sessions middleware stores the session under.
+	SessionContextKey = "session"
+
+	// maxCookieSize is the size browsers are required to support for a cookie.
+	maxCookieSize = 4096
+)
+
+var (
+	// ErrSessionNotFound is returned by the session helpers when the Sessions
+	// middleware did not run for the request.
+	ErrSessionNotFound = errors.New("session middleware is not configured")
+
+	// ErrSessionCookieTooLarge is returned by CookieSessionStore when the
+	// session does not fit into a cookie.
+	ErrSessionCookieTooLarge = errors.New("session does not fit into a cookie")
+
+	// DefaultSessionsConfig is the default Sessions middleware config.
+	DefaultSessionsConfig = SessionsConfig{
+		Skipper:         DefaultSkipper,
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 12 * time.Hour,
+		CookieName:      "session",
+		CookiePath:      "/",
+		CookieSameSite:  http.SameSiteLaxMode,
+		TimeFunc:        time.Now,
+	}
+)
+
+// Sessions returns a Sessions middleware using store.
+//
+// The session of the request is available with `GetSession()`. It is saved
+// when the response is written and its cookie is only sent once the session
+// holds data, so visitors without a session do not get one.
+func Sessions(store SessionStore) echo.MiddlewareFunc {
+	c := DefaultSessionsConfig
+	c.Store = store
+	return SessionsWithConfig(c)
+}
+
+// SessionsWithConfig returns a Sessions middleware with config.
+// See: `Sessions()`.
+func SessionsWithConfig(config SessionsConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Store == nil {
+		panic("echo: session middleware requires a store")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultSessionsConfig.Skipper
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = DefaultSessionsConfig.IdleTimeout
+	}
+	if config.AbsoluteTimeout == 0 {
+		config.AbsoluteTimeout = DefaultSessionsConfig.AbsoluteTimeout
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultSessionsConfig.CookieName
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = DefaultSessionsConfig.CookiePath
+	}
+	if config.CookieHTTPOnly == nil {
+		httpOnly := true
+		config.CookieHTTPOnly = &httpOnly
+	}
+	if config.CookieSameSite == 0 {
+		config.CookieSameSite = DefaultSessionsConfig.CookieSameSite
+	}
+	if config.CookieSameSite == http.SameSiteNoneMode {
+		config.CookieSecure = true
+	}
+	if config.TimeFunc == nil {
+		config.TimeFunc = DefaultSessionsConfig.TimeFunc
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			s, err := config.loadSession(c)
+			if err != nil {
+				return err
+			}
+			c.Set(SessionContextKey, s)
+
+			c.Response().Before(func() {
+				if err := s.save(c); err != nil {
+					c.Logger().Error(err)
+				}
+			})
+			if err := next(c); err != nil {
+				return err
+			}
+			if !c.Response().Committed {
+				return s.save(c)
+			}
+			return nil
+		}
+	}
+}
+
+// loadSession returns the session of the request or a new one if there is
+// none or it timed out.
+func (config *SessionsConfig) loadSession(c echo.Context) (*Session, error) {
+	now := config.TimeFunc()
+	if cookie, err := c.Cookie(config.CookieName); err == nil && cookie.Value != "" {
+		s, err := config.Store.Load(cookie.Value)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			if now.Sub(s.LastAccessedAt) <= config.IdleTimeout && now.Sub(s.CreatedAt) <= config.AbsoluteTimeout {
+				s.config = config
+				s.LastAccessedAt = now
+				s.dirty = true // keep the idle timeout going
+				return s, nil
+			}
+			if err := config.Store.Delete(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Session{
+		ID:             newSessionID(),
+		Values:         map[string]interface{}{},
+		CreatedAt:      now,
+		LastAccessedAt: now,
+		isNew:          true,
+		config:         config,
+	}, nil
+}
+
+// save stores the session and sets its cookie if it changed since it was
+// loaded or last saved.
+func (s *Session) save(c echo.Context) error {
+	if !s.dirty || s.destroyed {
+		return nil
+	}
+	value, err := s.config.Store.Save(s)
+	if err != nil {
+		return err
+	}
+	s.isNew = false
+	s.dirty = false
+	c.SetCookie(s.config.cookie(value, int(s.config.IdleTimeout.Seconds())))
+	return nil
+}
+
+func (config *SessionsConfig) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     config.CookieName,
+		Value:    value,
+		Path:     config.CookiePath,
+		Domain:   config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   config.CookieSecure,
+		HttpOnly: *config.CookieHTTPOnly,
+		SameSite: config.CookieSameSite,
+	}
+}
+
+// GetSession returns the session of the request.
+func GetSession(c echo.Context) (*Session, error) {
+	s, ok := c.Get(SessionContextKey).(*Session)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return s, nil
+}
+
+// SaveSession stores the session right away instead of when the response is
+// written, e.g. before streaming a response.
+func SaveSession(c echo.Context) error {
+	s, err := GetSession(c)
+	if err != nil {
+		return err
+	}
+	s.dirty = true
+	return s.save(c)
+}
+
+// DestroySession removes the session from the store and expires its cookie,
+// e.g. on logout. A new session is not started for the rest of the request.
+// With CookieSessionStore there is nothing to remove, so copies of the old
+// cookie stay valid until they expire.
+func DestroySession(c echo.Context) error {
+	s, err := GetSession(c)
+	if err != nil {
+		return err
+	}
+	s.destroyed = true
+	if !s.isNew {
+		if err := s.config.Store.Delete(s); err != nil {
+			return err
+		}
+	}
+	c.SetCookie(s.config.cookie("", -1))
+	return nil
+}
+
+// RegenerateSession moves the session to a new ID and removes the old one
+// from the store. Call it whenever the privileges of the session change, e.g.
+// on login, to prevent session fixation. With CookieSessionStore copies of the
+// old cookie stay valid until they expire.
+func RegenerateSession(c echo.Context) error {
+	s, err := GetSession(c)
+	if err != nil {
+		return err
+	}
+	if !s.isNew {
+		if err := s.config.Store.Delete(s); err != nil {
+			return err
+		}
+	}
+	s.ID = newSessionID()
+	s.isNew = true
+	s.dirty = true
+	return nil
+}
+
+// Get returns the value stored under key.
+func (s *Session) Get(key string) interface{} {
+	return s.Values[key]
+}
+
+// Set stores val under key.
+func (s *Session) Set(key string, val interface{}) {
+	s.Values[key] = val
+	s.dirty = true
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+	s.dirty = true
+}
+
+// IsNew reports whether the session was started by this request.
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// AddFlash adds a message which is kept until it is read with Flashes, e.g.
+// to show it after a redirect.
+func (s *Session) AddFlash(msg string) {
+	s.flashes = append(s.flashes, msg)
+	s.dirty = true
+}
+
+// Flashes returns the flash messages and removes them from the session.
+func (s *Session) Flashes() []string {
+	flashes := s.flashes
+	if len(flashes) > 0 {
+		s.flashes = nil
+		s.dirty = true
+	}
+	return flashes
+}
+
+func newSessionID() string {
+	return hex.EncodeToString(randomBytes(32))
+}
+
+// NewCookieSessionStore returns a CookieSessionStore signing cookies with the
+// first key. All keys are accepted when verifying, which allows rotating keys
+// by putting a new key first.
+func NewCookieSessionStore(keys ...[]byte) *CookieSessionStore {
+	if len(keys) == 0 {
+		panic("echo: cookie session store requires a key")
+	}
+	for _, k := range keys {
+		if len(k) < 32 {
+			panic("echo: cookie session store keys must be at least 32 bytes")
+		}
+	}
+	return &CookieSessionStore{keys: keys}
+}
+
+// Load implements SessionStore.Load.
+func (store *CookieSessionStore) Load(value string) (*Session, error) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return nil, nil
+	}
+	payload, sig := value[:i], value[i+1:]
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, nil
+	}
+	valid := false
+	for _, key := range store.keys {
+		if hmac.Equal(mac, signSession(key, payload)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, nil // tampered or signed with a retired key
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, nil
+	}
+	var data sessionData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, nil
+	}
+	return data.session(), nil
+}
+
+// Save implements SessionStore.Save.
+func (store *CookieSessionStore) Save(s *Session) (string, error) {
+	b, err := json.Marshal(newSessionData(s))
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	value := payload + "." + base64.RawURLEncoding.EncodeToString(signSession(store.keys[0], payload))
+	if len(value) > maxCookieSize {
+		return "", ErrSessionCookieTooLarge
+	}
+	return value, nil
+}
+
+// Delete implements SessionStore.Delete. The data lives in the cookie, so
+// there is nothing to remove and copies of the cookie stay valid until they
+// expire.
+func (store *CookieSessionStore) Delete(s *Session) error {
+	return nil
+}
+
+func signSession(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func newSessionData(s *Session) sessionData {
+	return sessionData{
+		ID:             s.ID,
+		Values:         s.Values,
+		Flashes:        s.flashes,
+		CreatedAt:      s.CreatedAt.UnixNano(),
+		LastAccessedAt: s.LastAccessedAt.UnixNano(),
+	}
+}
+
+func (data sessionData) session() *Session {
+	if data.Values == nil {
+		data.Values = map[string]interface{}{}
+	}
+	return &Session{
+		ID:             data.ID,
+		Values:         data.Values,
+		flashes:        data.Flashes,
+		CreatedAt:      time.Unix(0, data.CreatedAt),
+		LastAccessedAt: time.Unix(0, data.LastAccessedAt),
+	}
+}
+
+// NewMemorySessionStore returns a MemorySessionStore evicting sessions which
+// were not used for ttl. It should match `SessionsConfig.IdleTimeout`.
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	if ttl <= 0 {
+		panic("echo: memory session store ttl must be positive")
+	}
+	store := &MemorySessionStore{
+		sessions: map[string]*Session{},
+		ttl:      ttl,
+		timeNow:  time.Now,
+	}
+	store.lastCleanup = store.timeNow()
+	return store
+}
+
+// Load implements SessionStore.Load.
+func (store *MemorySessionStore) Load(value string) (*Session, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.cleanup()
+	s, ok := store.sessions[value]
+	if !ok || store.timeNow().Sub(s.LastAccessedAt) > store.ttl {
+		return nil, nil
+	}
+	return copySession(s), nil
+}
+
+// Save implements SessionStore.Save.
+func (store *MemorySessionStore) Save(s *Session) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.cleanup()
+	store.sessions[s.ID] = copySession(s)
+	return s.ID, nil
+}
+
+// Delete implements SessionStore.Delete.
+func (store *MemorySessionStore) Delete(s *Session) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.sessions, s.ID)
+	return nil
+}
+
+// cleanup evicts expired sessions, at most once per ttl.
+func (store *MemorySessionStore) cleanup() {
+	now := store.timeNow()
+	if now.Sub(store.lastCleanup) < store.ttl {
+		return
+	}
+	for id, s := range store.sessions {
+		if now.Sub(s.LastAccessedAt) > store.ttl {
+			delete(store.sessions, id)
+		}
+	}
+	store.lastCleanup = now
+}
+
+// copySession copies the session so requests do not share the values map.
+func copySession(s *Session) *Session {
+	values := make(map[string]interface{}, len(s.Values))
+	for k, v := range s.Values {
+		values[k] = v
+	}
+	return &Session{
+		ID:             s.ID,
+		Values:         values,
+		flashes:        append([]string(nil), s.flashes...),
+		CreatedAt:      s.CreatedAt,
+		LastAccessedAt: s.LastAccessedAt,
+	}
+}
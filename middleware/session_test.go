@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSessionKey = []byte("0123456789abcdef0123456789abcdef")
+
+// newSessionEcho returns an Echo whose single route performs the session
+// operation named by the "do" query parameter.
+func newSessionEcho(config SessionsConfig) *echo.Echo {
+	e := echo.New()
+	e.Use(SessionsWithConfig(config))
+	e.GET("/", func(c echo.Context) error {
+		s, err := GetSession(c)
+		if err != nil {
+			return err
+		}
+		switch c.QueryParam("do") {
+		case "set":
+			s.Set("user", c.QueryParam("v"))
+			s.AddFlash("welcome")
+		case "login":
+			if err := RegenerateSession(c); err != nil {
+				return err
+			}
+			s.Set("user", "admin")
+		case "logout":
+			return DestroySession(c)
+		case "flash":
+			return c.String(http.StatusOK, strings.Join(s.Flashes(), ","))
+		case "id":
+			return c.String(http.StatusOK, s.ID)
+		}
+		user, _ := s.Get("user").(string)
+		return c.String(http.StatusOK, user)
+	})
+	return e
+}
+
+// sessionRequest performs a request with the given session cookie and returns
+// the response and the session cookie it sets, if any.
+func sessionRequest(e *echo.Echo, query string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "session" {
+			return rec, c
+		}
+	}
+	return rec, nil
+}
+
+func TestSessions(t *testing.T) {
+	stores := map[string]SessionStore{
+		"cookie": NewCookieSessionStore(testSessionKey),
+		"memory": NewMemorySessionStore(time.Hour),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			e := newSessionEcho(SessionsConfig{Store: store})
+
+			// no cookie until the session holds data
+			rec, cookie := sessionRequest(e, "", nil)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Nil(t, cookie)
+
+			_, cookie = sessionRequest(e, "do=set&v=jon", nil)
+			if !assert.NotNil(t, cookie) {
+				return
+			}
+			assert.True(t, cookie.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+			assert.Equal(t, 1800, cookie.MaxAge)
+
+			rec, cookie = sessionRequest(e, "", cookie)
+			assert.Equal(t, "jon", rec.Body.String())
+			assert.NotNil(t, cookie, "cookie is refreshed on every request")
+
+			// flashes are read once
+			rec, cookie = sessionRequest(e, "do=flash", cookie)
+			assert.Equal(t, "welcome", rec.Body.String())
+			rec, cookie = sessionRequest(e, "do=flash", cookie)
+			assert.Equal(t, "", rec.Body.String())
+
+			rec, logout := sessionRequest(e, "do=logout", cookie)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			if assert.NotNil(t, logout) {
+				assert.Equal(t, "", logout.Value)
+				assert.Equal(t, -1, logout.MaxAge)
+			}
+		})
+	}
+}
+
+func TestSessions_regenerate(t *testing.T) {
+	store := NewMemorySessionStore(time.Hour)
+	e := newSessionEcho(SessionsConfig{Store: store})
+
+	_, cookie := sessionRequest(e, "do=set&v=guest", nil)
+	rec, _ := sessionRequest(e, "do=id", cookie)
+	oldID := rec.Body.String()
+
+	_, newCookie := sessionRequest(e, "do=login", cookie)
+	if !assert.NotNil(t, newCookie) {
+		return
+	}
+	rec, _ = sessionRequest(e, "do=id", newCookie)
+	assert.NotEqual(t, oldID, rec.Body.String())
+	rec, _ = sessionRequest(e, "", newCookie)
+	assert.Equal(t, "admin", rec.Body.String())
+
+	// the old session is gone
+	rec, _ = sessionRequest(e, "", cookie)
+	assert.Equal(t, "", rec.Body.String())
+}
+
+func TestSessions_timeouts(t *testing.T) {
+	now := time.Now()
+	store := NewMemorySessionStore(time.Hour)
+	e := newSessionEcho(SessionsConfig{
+		Store:           store,
+		IdleTimeout:     10 * time.Minute,
+		AbsoluteTimeout: 25 * time.Minute,
+		TimeFunc:        func() time.Time { return now },
+	})
+	store.timeNow = func() time.Time { return now }
+
+	_, cookie := sessionRequest(e, "do=set&v=jon", nil)
+
+	// activity keeps the session alive
+	now = now.Add(9 * time.Minute)
+	rec, _ := sessionRequest(e, "", cookie)
+	assert.Equal(t, "jon", rec.Body.String())
+	now = now.Add(9 * time.Minute)
+	rec, _ = sessionRequest(e, "", cookie)
+	assert.Equal(t, "jon", rec.Body.String())
+
+	// idle for too long
+	now = now.Add(11 * time.Minute)
+	rec, _ = sessionRequest(e, "", cookie)
+	assert.Equal(t, "", rec.Body.String())
+
+	// too old however active
+	_, cookie = sessionRequest(e, "do=set&v=joe", nil)
+	for i := 0; i < 3; i++ {
+		now = now.Add(9 * time.Minute)
+		rec, _ = sessionRequest(e, "", cookie)
+	}
+	assert.Equal(t, "", rec.Body.String())
+}
+
+func TestSessions_notConfigured(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	_, err := GetSession(c)
+	assert.Equal(t, ErrSessionNotFound, err)
+	assert.Equal(t, ErrSessionNotFound, SaveSession(c))
+	assert.Equal(t, ErrSessionNotFound, DestroySession(c))
+	assert.Equal(t, ErrSessionNotFound, RegenerateSession(c))
+
+	assert.Panics(t, func() {
+		Sessions(nil)
+	})
+}
+
+func TestSaveSession(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mw := Sessions(NewCookieSessionStore(testSessionKey))
+	err := mw(func(c echo.Context) error {
+		s, _ := GetSession(c)
+		s.Set("user", "jon")
+		if err := SaveSession(c); err != nil {
+			return err
+		}
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Flush()
+		return nil
+	})(c)
+
+	assert.NoError(t, err)
+	assert.Len(t, rec.Result().Cookies(), 1)
+}
+
+func TestCookieSessionStore(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("o"), 32)
+	store := NewCookieSessionStore(oldKey)
+	s := &Session{ID: "id", Values: map[string]interface{}{"n": 1}, CreatedAt: time.Unix(10, 0), LastAccessedAt: time.Unix(20, 0)}
+	value, err := store.Save(s)
+	assert.NoError(t, err)
+
+	// rotated keys still verify old cookies
+	store = NewCookieSessionStore(testSessionKey, oldKey)
+	loaded, err := store.Load(value)
+	if assert.NoError(t, err) && assert.NotNil(t, loaded) {
+		assert.Equal(t, "id", loaded.ID)
+		assert.Equal(t, float64(1), loaded.Get("n"))
+		assert.Equal(t, time.Unix(10, 0), loaded.CreatedAt)
+	}
+
+	// tampered values are ignored
+	for _, v := range []string{value[:len(value)-2] + "AA", "x" + value, "nodot", ""} {
+		loaded, err = store.Load(v)
+		assert.NoError(t, err)
+		assert.Nil(t, loaded)
+	}
+
+	// retired keys do not verify
+	store = NewCookieSessionStore(testSessionKey)
+	loaded, _ = store.Load(value)
+	assert.Nil(t, loaded)
+
+	s.Values["big"] = strings.Repeat("x", maxCookieSize)
+	_, err = store.Save(s)
+	assert.Equal(t, ErrSessionCookieTooLarge, err)
+
+	assert.Panics(t, func() { NewCookieSessionStore() })
+	assert.Panics(t, func() { NewCookieSessionStore([]byte("short")) })
+}
+
+func TestMemorySessionStore_eviction(t *testing.T) {
+	now := time.Now()
+	store := NewMemorySessionStore(time.Minute)
+	store.timeNow = func() time.Time { return now }
+
+	store.Save(&Session{ID: "a", Values: map[string]interface{}{}, LastAccessedAt: now})
+	now = now.Add(30 * time.Second)
+	store.Save(&Session{ID: "b", Values: map[string]interface{}{}, LastAccessedAt: now})
+
+	// expired sessions are not returned even before they are swept
+	now = now.Add(45 * time.Second)
+	s, _ := store.Load("a")
+	assert.Nil(t, s)
+
+	now = now.Add(time.Second)
+	s, _ = store.Load("b")
+	assert.NotNil(t, s)
+	assert.NotContains(t, store.sessions, "a")
+
+	// loaded sessions do not share values with the store
+	s.Set("k", "v")
+	s2, _ := store.Load("b")
+	assert.Nil(t, s2.Get("k"))
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// TrailingSlashConfig defines the config for TrailingSlash middleware.
+	TrailingSlashConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Status code to be used when redirecting the request.
+		// Optional, but when provided the request is redirected using this code,
+		// e.g. 301 or 308, instead of being rewritten internally.
+		RedirectCode int
+	}
+)
+
+var (
+	// DefaultTrailingSlashConfig is the default TrailingSlash middleware config.
+	DefaultTrailingSlashConfig = TrailingSlashConfig{
+		Skipper: DefaultSkipper,
+	}
+)
+
+// AddTrailingSlash returns a root level (before router) middleware which adds a
+// trailing slash to the request `URL#Path`.
+//
+// Usage `Echo#Pre(AddTrailingSlash())`
+func AddTrailingSlash() echo.MiddlewareFunc {
+	return AddTrailingSlashWithConfig(DefaultTrailingSlashConfig)
+}
+
+// AddTrailingSlashWithConfig returns an AddTrailingSlash middleware with config.
+// See `AddTrailingSlash()`.
+func AddTrailingSlashWithConfig(config TrailingSlashConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTrailingSlashConfig.Skipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			url := req.URL
+			if !strings.HasSuffix(url.Path, "/") {
+				return slashRedirectOrForward(c, next, config, url.Path+"/", appendSlash(url.RawPath))
+			}
+			return next(c)
+		}
+	}
+}
+
+// RemoveTrailingSlash returns a root level (before router) middleware which removes
+// a trailing slash from the request URI.
+//
+// Usage `Echo#Pre(RemoveTrailingSlash())`
+func RemoveTrailingSlash() echo.MiddlewareFunc {
+	return RemoveTrailingSlashWithConfig(DefaultTrailingSlashConfig)
+}
+
+// RemoveTrailingSlashWithConfig returns a RemoveTrailingSlash middleware with config.
+// See `RemoveTrailingSlash()`.
+func RemoveTrailingSlashWithConfig(config TrailingSlashConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTrailingSlashConfig.Skipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			url := req.URL
+			if l := len(url.Path); l > 1 && url.Path[l-1] == '/' {
+				return slashRedirectOrForward(c, next, config, url.Path[:l-1], strings.TrimSuffix(url.RawPath, "/"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// slashRedirectOrForward either redirects the request to the new path or
+// rewrites the request in place and continues the chain.
+func slashRedirectOrForward(c echo.Context, next echo.HandlerFunc, config TrailingSlashConfig, path, rawPath string) error {
+	req := c.Request()
+	uri := path
+	if rawPath != "" {
+		uri = rawPath
+	}
+	if req.URL.RawQuery != "" {
+		uri += "?" + req.URL.RawQuery
+	}
+
+	// Redirect
+	if config.RedirectCode != 0 {
+		return c.Redirect(config.RedirectCode, sanitizeURI(uri))
+	}
+
+	// Forward
+	req.RequestURI = uri
+	req.URL.Path = path
+	req.URL.RawPath = rawPath
+	return next(c)
+}
+
+func appendSlash(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + "/"
+}
+
+// sanitizeURI collapses leading slashes and backslashes, which browsers treat
+// as a scheme-relative URL, so a redirect can not lead to another host.
+func sanitizeURI(uri string) string {
+	if len(uri) > 1 && (uri[0] == '\\' || uri[0] == '/') && (uri[1] == '\\' || uri[1] == '/') {
+		uri = "/" + strings.TrimLeft(uri, `/\`)
+	}
+	return uri
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTrailingSlash(t *testing.T) {
+	testCases := []struct {
+		whenURL        string
+		whenCode       int
+		expectPath     string
+		expectRawPath  string
+		expectLocation string
+		expectCode     int
+	}{
+		{whenURL: "/add-slash", expectPath: "/add-slash/", expectCode: http.StatusOK},
+		{whenURL: "/add-slash/", expectPath: "/add-slash/", expectCode: http.StatusOK},
+		{whenURL: "/", expectPath: "/", expectCode: http.StatusOK},
+		{whenURL: "/a%2Fb", expectPath: "/a/b/", expectRawPath: "/a%2Fb/", expectCode: http.StatusOK},
+		{
+			whenURL:        "/add-slash?key=value",
+			whenCode:       http.StatusMovedPermanently,
+			expectPath:     "/add-slash",
+			expectLocation: "/add-slash/?key=value",
+			expectCode:     http.StatusMovedPermanently,
+		},
+		{
+			whenURL:        "/a%2Fb",
+			whenCode:       http.StatusPermanentRedirect,
+			expectPath:     "/a/b",
+			expectRawPath:  "/a%2Fb",
+			expectLocation: "/a%2Fb/",
+			expectCode:     http.StatusPermanentRedirect,
+		},
+		{
+			whenURL:        "//evil.com",
+			whenCode:       http.StatusMovedPermanently,
+			expectPath:     "//evil.com",
+			expectLocation: "/evil.com/",
+			expectCode:     http.StatusMovedPermanently,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			e := echo.New()
+			mw := AddTrailingSlashWithConfig(TrailingSlashConfig{RedirectCode: tc.whenCode})
+			h := mw(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.whenURL, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.NoError(t, h(c))
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectPath, req.URL.Path)
+			assert.Equal(t, tc.expectRawPath, req.URL.RawPath)
+			assert.Equal(t, tc.expectLocation, rec.Header().Get(echo.HeaderLocation))
+		})
+	}
+}
+
+func TestRemoveTrailingSlash(t *testing.T) {
+	testCases := []struct {
+		whenURL        string
+		whenCode       int
+		expectPath     string
+		expectRawPath  string
+		expectLocation string
+		expectCode     int
+	}{
+		{whenURL: "/remove-slash/", expectPath: "/remove-slash", expectCode: http.StatusOK},
+		{whenURL: "/remove-slash", expectPath: "/remove-slash", expectCode: http.StatusOK},
+		{whenURL: "/", expectPath: "/", expectCode: http.StatusOK},
+		{whenURL: "/a%2Fb/", expectPath: "/a/b", expectRawPath: "/a%2Fb", expectCode: http.StatusOK},
+		{
+			whenURL:        "/remove-slash/?key=value",
+			whenCode:       http.StatusMovedPermanently,
+			expectPath:     "/remove-slash/",
+			expectLocation: "/remove-slash?key=value",
+			expectCode:     http.StatusMovedPermanently,
+		},
+		{
+			whenURL:        "http://localhost///evil.com/",
+			whenCode:       http.StatusPermanentRedirect,
+			expectPath:     "///evil.com/",
+			expectLocation: "/evil.com",
+			expectCode:     http.StatusPermanentRedirect,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			e := echo.New()
+			mw := RemoveTrailingSlashWithConfig(TrailingSlashConfig{RedirectCode: tc.whenCode})
+			h := mw(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.whenURL, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.NoError(t, h(c))
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectPath, req.URL.Path)
+			assert.Equal(t, tc.expectRawPath, req.URL.RawPath)
+			assert.Equal(t, tc.expectLocation, rec.Header().Get(echo.HeaderLocation))
+		})
+	}
+}
+
+func TestRemoveTrailingSlash_pre(t *testing.T) {
+	e := echo.New()
+	e.Pre(RemoveTrailingSlash())
+	e.GET("/users", func(c echo.Context) error {
+		return c.String(http.StatusOK, "users")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "users", rec.Body.String())
+}
@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+type (
+	// TimeoutConfig defines the config for Timeout middleware.
+	TimeoutConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Timeout configures a timeout for the middleware, the middleware is
+		// skipped when it is 0 or negative.
+		Timeout time.Duration
+
+		// TimeoutError is returned to the error handler when the handler
+		// overruns Timeout, usually `echo.ErrServiceUnavailable` or
+		// `echo.ErrRequestTimeout`. When the request is canceled before, e.g.
+		// the client disconnects, `context.Canceled` is returned instead.
+		// Optional. Default value echo.ErrServiceUnavailable.
+		TimeoutError error
+
+		// OnTimeoutRouteErrorHandler is called with the error a handler returns
+		// or panics with after the timeout already fired. The response has been
+		// sent at that point, so it is only good for logging.
+		// Optional. By default the error is logged.
+		OnTimeoutRouteErrorHandler func(err error, c echo.Context)
+	}
+
+	// timeoutWriter buffers the handler output until the middleware decides
+	// whether it is sent or dropped.
+	timeoutWriter struct {
+		mu       sync.Mutex
+		header   http.Header
+		body     bytes.Buffer
+		code     int
+		finished bool
+		timedOut bool
+	}
+)
+
+var (
+	// DefaultTimeoutConfig is the default Timeout middleware config.
+	DefaultTimeoutConfig = TimeoutConfig{
+		Skipper:      DefaultSkipper,
+		Timeout:      0,
+		TimeoutError: echo.ErrServiceUnavailable,
+	}
+)
+
+// Timeout returns a middleware which fails the request with
+// `echo.ErrServiceUnavailable` when the handler takes longer than timeout.
+func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+	c := DefaultTimeoutConfig
+	c.Timeout = timeout
+	return TimeoutWithConfig(c)
+}
+
+// TimeoutWithConfig returns a Timeout middleware with config.
+//
+// The handler runs in its own goroutine on a copy of the context whose request
+// carries a deadline bound `context.Context`, handlers doing long work should
+// watch `c.Request().Context().Done()`. Its output is buffered and only sent
+// when it finishes in time, later writes fail with `http.ErrHandlerTimeout`.
+// The copy is released to the pool by the handler goroutine itself, so it is
+// never reused while a timed out handler still runs.
+// See: `Timeout()`.
+func TimeoutWithConfig(config TimeoutConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTimeoutConfig.Skipper
+	}
+	if config.TimeoutError == nil {
+		config.TimeoutError = DefaultTimeoutConfig.TimeoutError
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) || config.Timeout <= 0 {
+				return next(c)
+			}
+
+			e := c.Echo()
+			ctx, cancel := context.WithTimeout(c.Request().Context(), config.Timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: http.Header{}}
+			tc := e.ForkContext(c, c.Request().WithContext(ctx), tw)
+
+			done := make(chan error, 1)
+			panicked := make(chan interface{}, 1)
+			go func() {
+				var err error
+				defer func() {
+					p := recover()
+					if tw.finish() {
+						// The middleware returned already, this goroutine owns tc.
+						if p != nil {
+							err = fmt.Errorf("[PANIC RECOVER] %v", p)
+						}
+						if err != nil {
+							if config.OnTimeoutRouteErrorHandler != nil {
+								config.OnTimeoutRouteErrorHandler(err, tc)
+							} else {
+								e.Logger.Errorf("timeout: handler error after timeout: %v", err)
+							}
+						}
+						e.ReleaseContext(tc)
+						return
+					}
+					if p != nil {
+						panicked <- p
+						return
+					}
+					done <- err
+				}()
+				err = next(tc)
+			}()
+
+			select {
+			case p := <-panicked:
+				e.ReleaseContext(tc)
+				panic(p) // let Recover handle it with the original context
+			case err := <-done:
+				defer e.ReleaseContext(tc)
+				return tw.writeTo(c.Response(), err)
+			case <-ctx.Done():
+				if !tw.timeout() {
+					// The handler finished while the deadline fired.
+					select {
+					case p := <-panicked:
+						e.ReleaseContext(tc)
+						panic(p)
+					case err := <-done:
+						defer e.ReleaseContext(tc)
+						return tw.writeTo(c.Response(), err)
+					}
+				}
+				if ctx.Err() != context.DeadlineExceeded {
+					// The request was canceled, e.g. the client went away.
+					return ctx.Err()
+				}
+				return config.TimeoutError
+			}
+		}
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.code != 0 {
+		return
+	}
+	w.code = code
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Flush is a no-op, the output is sent at once when the handler returns.
+func (w *timeoutWriter) Flush() {}
+
+// finish marks the handler as returned and reports whether it timed out.
+func (w *timeoutWriter) finish() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+	return w.timedOut
+}
+
+// timeout marks the handler as timed out unless it finished already and
+// reports whether it did so.
+func (w *timeoutWriter) timeout() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.finished {
+		return false
+	}
+	w.timedOut = true
+	return true
+}
+
+// writeTo sends the buffered output of a finished handler to res.
+func (w *timeoutWriter) writeTo(res *echo.Response, err error) error {
+	dst := res.Header()
+	for k, v := range w.header {
+		dst[k] = v
+	}
+	if w.code != 0 {
+		res.WriteHeader(w.code)
+		if _, werr := w.body.WriteTo(res); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ken2mer/echo-mini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(time.Second))
+	e.GET("/", func(c echo.Context) error {
+		_, ok := c.Request().Context().Deadline()
+		assert.True(t, ok)
+		c.Response().Header().Set("X-Handler", "done")
+		return c.String(http.StatusCreated, c.Get("user").(string))
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", "jon")
+			return next(c)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "done", rec.Header().Get("X-Handler"))
+	assert.Equal(t, "jon", rec.Body.String())
+}
+
+func TestTimeout_handlerError(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(time.Second))
+	e.GET("/", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot, "nope")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "\"nope\"\n", rec.Body.String())
+}
+
+func TestTimeout_overrun(t *testing.T) {
+	testCases := []struct {
+		name         string
+		timeoutError error
+		expectCode   int
+	}{
+		{name: "default is 503", expectCode: http.StatusServiceUnavailable},
+		{name: "request timeout", timeoutError: echo.ErrRequestTimeout, expectCode: http.StatusRequestTimeout},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			release := make(chan struct{})
+			lateErr := make(chan error, 1)
+			lateWrite := make(chan error, 1)
+
+			e := echo.New()
+			e.Use(TimeoutWithConfig(TimeoutConfig{
+				Timeout:      20 * time.Millisecond,
+				TimeoutError: tc.timeoutError,
+				OnTimeoutRouteErrorHandler: func(err error, c echo.Context) {
+					lateErr <- err
+				},
+			}))
+			e.GET("/", func(c echo.Context) error {
+				<-c.Request().Context().Done()
+				<-release
+				_, err := c.Response().Write([]byte("late"))
+				lateWrite <- err
+				return errors.New("late failure")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			body := rec.Body.String()
+
+			// The next request reuses pooled contexts while the handler still runs.
+			e2rec := httptest.NewRecorder()
+			e.ServeHTTP(e2rec, httptest.NewRequest(http.MethodPost, "/", nil))
+			assert.Equal(t, http.StatusMethodNotAllowed, e2rec.Code)
+
+			close(release)
+			assert.Equal(t, http.ErrHandlerTimeout, <-lateWrite)
+			assert.EqualError(t, <-lateErr, "late failure")
+			assert.Equal(t, body, rec.Body.String())
+		})
+	}
+}
+
+func TestTimeout_canceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	e := echo.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := Timeout(time.Second)(func(c echo.Context) error {
+		cancel()
+		<-release
+		return nil
+	})
+	assert.Equal(t, context.Canceled, h(c))
+}
+
+func TestTimeout_panic(t *testing.T) {
+	e := echo.New()
+	e.Use(Recover(), Timeout(time.Second))
+	e.GET("/", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestTimeout_skipped(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := Timeout(0)(func(c echo.Context) error {
+		_, ok := c.Request().Context().Deadline()
+		assert.False(t, ok)
+		return c.NoContent(http.StatusNoContent)
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bufio"
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/Ken2mer/echo-mini"
+)
+
+// randomReader buffers crypto/rand so that the many small reads done per
+// request (IDs, tokens, nonces) do not each end up in a syscall.
+var randomReader = struct {
+	sync.Mutex
+	r *bufio.Reader
+}{r: bufio.NewReader(rand.Reader)}
+
+// randomBytes returns n cryptographically secure random bytes.
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	randomReader.Lock()
+	_, err := io.ReadFull(randomReader.r, b)
+	randomReader.Unlock()
+	if err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	return b
+}
+
+const randomStringCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// randomString returns a random alphanumeric string of the given length.
+func randomString(length uint8) string {
+	// Bytes above the largest multiple of the charset length are dropped so
+	// that every character is equally likely.
+	const max = 256 - 256%len(randomStringCharset)
+
+	b := make([]byte, 0, length)
+	for len(b) < int(length) {
+		for _, r := range randomBytes(int(length)) {
+			if int(r) >= max {
+				continue
+			}
+			b = append(b, randomStringCharset[int(r)%len(randomStringCharset)])
+			if len(b) == int(length) {
+				break
+			}
+		}
+	}
+	return string(b)
+}
+
+// readCloser pairs a reader wrapping a request body with the Close of the
+// original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedReader fails with echo.ErrStatusRequestEntityTooLarge once more than
+// limit bytes have been read from reader.
+type limitedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (r *limitedReader) Read(b []byte) (n int, err error) {
+	n, err = r.reader.Read(b)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n, echo.ErrStatusRequestEntityTooLarge
+	}
+	return
+}
@@ -1,6 +1,11 @@
 package echo
 
-import "net/http"
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
 
 type (
 	Response struct {
@@ -9,8 +14,13 @@ type (
 		afterFuncs  []func()
 		Writer      http.ResponseWriter
 		Status      int
-		// Size        int64
-		Committed bool
+		Size        int64
+		Committed   bool
+	}
+
+	// rwUnwrapper is implemented by http.ResponseWriter wrappers.
+	rwUnwrapper interface {
+		Unwrap() http.ResponseWriter
 	}
 )
 
@@ -31,16 +41,16 @@ func (r *Response) After(fn func()) {
 }
 
 func (r *Response) WriteHeader(code int) {
-	// if r.Committed {
-	// 	r.echo.Logger.Warn("response already committed")
-	// 	return
-	// }
+	if r.Committed {
+		r.echo.Logger.Warn("response already committed")
+		return
+	}
 	r.Status = code
 	for _, fn := range r.beforeFuncs {
 		fn()
 	}
 	r.Writer.WriteHeader(r.Status)
-	// r.Committed = true
+	r.Committed = true
 }
 
 func (r *Response) Write(b []byte) (n int, err error) {
@@ -51,22 +61,66 @@ func (r *Response) Write(b []byte) (n int, err error) {
 		r.WriteHeader(r.Status)
 	}
 	n, err = r.Writer.Write(b)
-	// r.Size += int64(n)
+	r.Size += int64(n)
 	for _, fn := range r.afterFuncs {
 		fn()
 	}
 	return
 }
 
+// Flush implements the http.Flusher interface to allow an HTTP handler to flush
+// buffered data to the client. A response which is not committed yet is
+// committed first, so the status and the `Before` functions apply to streamed
+// responses, e.g. over HTTP/2 where the headers are sent on the first flush.
+// See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
 func (r *Response) Flush() {
-	r.Writer.(http.Flusher).Flush()
+	if !r.Committed {
+		if r.Status == 0 {
+			r.Status = http.StatusOK
+		}
+		r.WriteHeader(r.Status)
+	}
+	for w := r.Writer; ; {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+			return
+		}
+		u, ok := w.(rwUnwrapper)
+		if !ok {
+			panic(errors.New("echo: response writer flushing is not supported"))
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Hijack implements the http.Hijacker interface to allow an HTTP handler to
+// take over the connection. HTTP/2 connections can not be hijacked.
+// See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	for w := r.Writer; ; {
+		if h, ok := w.(http.Hijacker); ok {
+			return h.Hijack()
+		}
+		u, ok := w.(rwUnwrapper)
+		if !ok {
+			return nil, nil, errors.New("echo: response writer hijacking is not supported")
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+// ResponseController can be used to access the original http.ResponseWriter.
+// See [https://go.dev/blog/go1.20]
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.Writer
 }
 
 func (r *Response) reset(w http.ResponseWriter) {
-	// r.beforeFuncs = nil
-	// r.afterFuncs = nil
+	r.beforeFuncs = nil
+	r.afterFuncs = nil
 	r.Writer = w
-	// r.Size = 0
-	// r.Status = http.StatusOK
-	// r.Committed = false
+	r.Size = 0
+	r.Status = http.StatusOK
+	r.Committed = false
 }
@@ -57,6 +57,52 @@ func TestResponse_Flush(t *testing.T) {
 	assert.True(t, rec.Flushed)
 }
 
+func TestResponse_Flush_commits(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec, e)
+	res.Before(func() {
+		res.Header().Set("X-Before", "1")
+	})
+
+	// flushing before anything was written commits the response
+	res.Flush()
+	assert.True(t, res.Committed)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Before"))
+	assert.True(t, rec.Flushed)
+}
+
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestResponse_Flush_unwrap(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	res := NewResponse(&unwrapWriter{rec}, e)
+
+	res.Flush()
+	assert.True(t, rec.Flushed)
+
+	res = NewResponse(struct{ http.ResponseWriter }{rec}, e)
+	assert.Panics(t, func() {
+		res.Flush()
+	})
+}
+
+func TestResponse_Hijack_notSupported(t *testing.T) {
+	e := New()
+	res := NewResponse(httptest.NewRecorder(), e)
+
+	_, _, err := res.Hijack()
+	assert.Error(t, err)
+}
+
 func TestResponse_ChangeStatusCodeBeforeWrite(t *testing.T) {
 	e := New()
 	rec := httptest.NewRecorder()
@@ -72,3 +118,11 @@ func TestResponse_ChangeStatusCodeBeforeWrite(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
+
+func TestResponse_Unwrap(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	res := &Response{echo: e, Writer: rec}
+
+	assert.Equal(t, rec, res.Unwrap())
+}
@@ -0,0 +1,192 @@
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// envListenFDNames passes the names of the listeners inherited from
+// `Echo#Restart()`, separated by ":", in the order of their file descriptors.
+const envListenFDNames = "ECHO_LISTEN_FDNAMES"
+
+const (
+	listenerNameHTTP  = "http"
+	listenerNameHTTPS = "https"
+)
+
+// restartState tracks the inherited listeners of a process started by
+// `Echo#Restart()` until they are all served and the parent is told so.
+type restartState struct {
+	ready   *os.File
+	pending map[string]bool
+}
+
+// Restart replaces the running process without dropping connections. It starts
+// the executable again, with the same arguments and environment, passing on
+// `Echo#Listener` and `Echo#TLSListener` as file descriptors. Once the new
+// process serves all of them the server is shut down gracefully with ctx, so
+// in-flight requests are completed. The Start* methods return
+// `http.ErrServerClosed` as soon as the shutdown starts, so wait for Restart to
+// return before exiting the process.
+//
+// The new process takes the listeners over with `Echo#InheritListeners()`. If
+// it exits before it's ready or ctx is done first, it is killed and the server
+// keeps running.
+//
+// Restart must not be called from a handler, as the shutdown waits for it.
+func (e *Echo) Restart(ctx stdContext.Context) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("echo: restart is not supported on windows")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	e.startupMutex.RLock()
+	var names []string
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, l := range []struct {
+		name     string
+		listener net.Listener
+	}{
+		{name: listenerNameHTTP, listener: e.Listener},
+		{name: listenerNameHTTPS, listener: e.rawTLSListener},
+	} {
+		if l.listener == nil {
+			continue
+		}
+		f, err := listenerFile(l.listener)
+		if err != nil {
+			e.startupMutex.RUnlock()
+			return fmt.Errorf("echo: %s listener can not be passed on: %w", l.name, err)
+		}
+		names = append(names, l.name)
+		files = append(files, f)
+	}
+	e.startupMutex.RUnlock()
+	if len(files) == 0 {
+		return errors.New("echo: no listener to pass on")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), envListenFDNames+"="+strings.Join(names, ":"))
+	// The ready pipe follows the listeners.
+	cmd.ExtraFiles = append(files, w)
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	// The child writes to the pipe once ready, it's closed without a write
+	// when the child exits.
+	ready := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("echo: restarted process did not start: %w", err)
+	}
+	// The child is not waited for, it outlives this process.
+	cmd.Process.Release()
+
+	// Keep the socket file the child serves now.
+	if ul, ok := e.Listener.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	return e.Shutdown(ctx)
+}
+
+// listenerFile returns a duplicate of the file descriptor of l.
+func listenerFile(l net.Listener) (*os.File, error) {
+	fl, ok := l.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("%T has no file descriptor", l)
+	}
+	return fl.File()
+}
+
+// InheritListeners sets `Echo#Listener` and the listener of `Echo#TLSListener`
+// to the listeners passed on by `Echo#Restart()` of the parent process. Call it
+// before starting the servers, e.g. with `Start()` and `StartTLS()`, which then
+// ignore their address. The parent is told to shut down once every inherited
+// listener is served.
+//
+// It returns false if the process was not started by `Echo#Restart()`.
+func (e *Echo) InheritListeners() (bool, error) {
+	fdNames := os.Getenv(envListenFDNames)
+	if fdNames == "" {
+		return false, nil
+	}
+	os.Unsetenv(envListenFDNames)
+
+	names := strings.Split(fdNames, ":")
+	listeners, err := e.inheritedListeners(len(names), names)
+	if err != nil {
+		return false, err
+	}
+
+	e.startupMutex.Lock()
+	defer e.startupMutex.Unlock()
+	e.restart = &restartState{
+		ready:   os.NewFile(uintptr(listenFDsStart+len(names)), "ready"),
+		pending: make(map[string]bool),
+	}
+	for i, name := range names {
+		l := listeners[i]
+		switch name {
+		case listenerNameHTTP:
+			e.Listener = l
+		case listenerNameHTTPS:
+			e.rawTLSListener = l
+		default:
+			l.Close()
+			continue
+		}
+		e.restart.pending[name] = true
+	}
+	return true, nil
+}
+
+// inheritedListenerStarted tells the parent process the restart is complete
+// once all inherited listeners are started. It's called with startupMutex held.
+func (e *Echo) inheritedListenerStarted(name string) {
+	if e.restart == nil || e.restart.ready == nil {
+		return
+	}
+	delete(e.restart.pending, name)
+	if len(e.restart.pending) == 0 {
+		e.restart.ready.Write([]byte{1})
+		e.restart.ready.Close()
+		e.restart.ready = nil
+	}
+}
@@ -0,0 +1,186 @@
+package echo
+
+import (
+	"bufio"
+	stdContext "context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// restartTestServer is run by TestEchoRestart in a child process, and again in
+// the process started by Restart. It answers with its pid.
+func restartTestServer(t *testing.T) {
+	cert, key := testCertificate(t)
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	restarted := make(chan struct{})
+	e.GET("/", func(c Context) error {
+		switch {
+		case c.QueryParam("restart") != "":
+			go func() {
+				if err := e.Restart(stdContext.Background()); err != nil {
+					fmt.Println("restart:", err)
+					os.Exit(1)
+				}
+				close(restarted)
+			}()
+		case c.QueryParam("slow") != "":
+			time.Sleep(500 * time.Millisecond)
+		}
+		return c.String(http.StatusOK, strconv.Itoa(os.Getpid()))
+	})
+
+	inherited, err := e.InheritListeners()
+	if err != nil {
+		fmt.Println("inherit:", err)
+		os.Exit(1)
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	go func() {
+		errCh <- e.StartTLS("127.0.0.1:0", cert, key)
+	}()
+	if !inherited {
+		if err := waitForServerStart(e, errCh, false); err != nil {
+			os.Exit(1)
+		}
+		if err := waitForServerStart(e, errCh, true); err != nil {
+			os.Exit(1)
+		}
+		fmt.Printf("ECHO_TEST_ADDRS %s %s\n", e.ListenerAddr(), e.TLSListenerAddr())
+	}
+	<-errCh
+	<-errCh
+	// Serve returns as soon as the shutdown starts, in-flight requests are
+	// completed once Restart returns.
+	<-restarted
+	os.Exit(0)
+}
+
+func TestEchoRestart(t *testing.T) {
+	if os.Getenv("ECHO_TEST_RESTART") == "1" {
+		restartTestServer(t)
+		return
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("restart is not supported on windows")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestEchoRestart$")
+	cmd.Env = append(os.Environ(), "ECHO_TEST_RESTART=1")
+	// Not cmd.StdoutPipe, it's closed by Wait while the restarted process
+	// still writes to it.
+	stdout, w, err := os.Pipe()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stdout.Close()
+	cmd.Stdout = w
+	err = cmd.Start()
+	w.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	defer func() {
+		cmd.Process.Kill()
+		<-done
+	}()
+
+	var httpAddr, httpsAddr string
+	br := bufio.NewReader(stdout)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("server did not start: %v", err)
+		}
+		if strings.HasPrefix(line, "ECHO_TEST_ADDRS ") {
+			fields := strings.Fields(line)
+			httpAddr, httpsAddr = fields[1], fields[2]
+			break
+		}
+	}
+	go io.Copy(ioutil.Discard, br)
+
+	client := tlsClient()
+	client.Timeout = 10 * time.Second
+	get := func(url string) string {
+		res, err := client.Get(url)
+		if err != nil {
+			return err.Error()
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		return string(body)
+	}
+
+	parentPid := strconv.Itoa(cmd.Process.Pid)
+	assert.Equal(t, parentPid, get("http://"+httpAddr+"/"))
+	assert.Equal(t, parentPid, get("https://"+httpsAddr+"/"))
+
+	// an in-flight request is completed by the parent
+	slow := make(chan string, 1)
+	go func() {
+		slow <- get("http://" + httpAddr + "/?slow=1")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, parentPid, get("http://"+httpAddr+"/?restart=1"))
+
+	var childPid string
+	for i := 0; i < 100; i++ {
+		if pid := get("http://" + httpAddr + "/"); pid != parentPid {
+			childPid = pid
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if pid, err := strconv.Atoi(childPid); assert.NoError(t, err) {
+		defer func() {
+			if p, err := os.FindProcess(pid); err == nil {
+				p.Kill()
+			}
+		}()
+	}
+	assert.Equal(t, parentPid, <-slow)
+	assert.Equal(t, childPid, get("https://"+httpsAddr+"/"))
+
+	// the parent exits once shut down
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		done <- err
+	case <-time.After(10 * time.Second):
+		t.Fatal("parent process did not exit")
+	}
+}
+
+func TestEchoRestart_noListener(t *testing.T) {
+	e := New()
+	assert.EqualError(t, e.Restart(stdContext.Background()), "echo: no listener to pass on")
+}
+
+func TestEchoInheritListeners_notRestarted(t *testing.T) {
+	e := New()
+	inherited, err := e.InheritListeners()
+	assert.NoError(t, err)
+	assert.False(t, inherited)
+	assert.Nil(t, e.ListenerAddr())
+}
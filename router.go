@@ -1,10 +1,13 @@
 package echo
 
 import (
+	"bytes"
 	"net/http"
 )
 
 type (
+	// Router matches requests against a prefix tree of the static route
+	// paths, with the handlers kept per method on the node of their path.
 	Router struct {
 		tree   *node
 		routes map[string]*Route
@@ -12,28 +15,42 @@ type (
 	}
 
 	node struct {
-		kind kind
-		// label  byte
-		prefix string
-		// parent *node
-		// staticChildren children
-		// ppath         string
+		kind           kind
+		label          byte
+		prefix         string
+		parent         *node
+		staticChildren children
+		ppath          string
 		// pnames        []string
 		methodHandler *methodHandler
 		// paramChild     *node
 		// anyChild       *node
 
 		// isLeaf indicates that node does not have child routes
-		// isLeaf bool
+		isLeaf bool
 
 		// isHandler indicates that node has at least one handler registered to it
 		isHandler bool
 	}
 
 	kind          uint8
-	// children      []*node
+	children      []*node
 	methodHandler struct {
-		get HandlerFunc
+		connect  HandlerFunc
+		delete   HandlerFunc
+		get      HandlerFunc
+		head     HandlerFunc
+		options  HandlerFunc
+		patch    HandlerFunc
+		post     HandlerFunc
+		propfind HandlerFunc
+		put      HandlerFunc
+		trace    HandlerFunc
+		report   HandlerFunc
+
+		// allowHeader is the value of the `Allow` header for the methods
+		// registered on the node.
+		allowHeader string
 	}
 )
 
@@ -53,12 +70,12 @@ func NewRouter(e *Echo) *Router {
 
 func (r *Router) Add(method, path string, h HandlerFunc) {
 	// Validate path
-	// if path == "" {
-	// 	path = "/"
-	// }
-	// if path[0] != '/' {
-	// 	path = "/" + path
-	// }
+	if path == "" {
+		path = "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
 
 	pnames := []string{} // Param names
 	ppath := path        // Pristine path
@@ -101,55 +118,216 @@ func (r *Router) insert(method, path string, h HandlerFunc, t kind, ppath string
 	search := path
 
 	for {
-		// searchLen := len(search)
-		// prefixLen := len(currentNode.prefix)
+		searchLen := len(search)
+		prefixLen := len(currentNode.prefix)
 		lcpLen := 0
 
 		// LCP - Longest Common Prefix (https://en.wikipedia.org/wiki/LCP_array)
-		// max := prefixLen
-		// if searchLen < max {
-		// 	max = searchLen
-		// }
-		// for ; lcpLen < max && search[lcpLen] == currentNode.prefix[lcpLen]; lcpLen++ {
-		// }
+		max := prefixLen
+		if searchLen < max {
+			max = searchLen
+		}
+		for ; lcpLen < max && search[lcpLen] == currentNode.prefix[lcpLen]; lcpLen++ {
+		}
 
 		if lcpLen == 0 {
 			// At root node
-			// currentNode.label = search[0]
+			currentNode.label = search[0]
 			currentNode.prefix = search
 			if h != nil {
-				// currentNode.kind = t
+				currentNode.kind = t
 				currentNode.addHandler(method, h)
-				// currentNode.ppath = ppath
+				currentNode.ppath = ppath
 				// currentNode.pnames = pnames
 			}
-			// currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChild == nil && currentNode.anyChild == nil
+			currentNode.isLeaf = currentNode.staticChildren == nil
+		} else if lcpLen < prefixLen {
+			// Split node
+			n := newNode(
+				currentNode.kind,
+				currentNode.prefix[lcpLen:],
+				currentNode,
+				currentNode.staticChildren,
+				currentNode.methodHandler,
+				currentNode.ppath,
+			)
+			// Update parent path for all children to new node
+			for _, child := range currentNode.staticChildren {
+				child.parent = n
+			}
+
+			// Reset parent node
+			currentNode.kind = staticKind
+			currentNode.label = currentNode.prefix[0]
+			currentNode.prefix = currentNode.prefix[:lcpLen]
+			currentNode.staticChildren = nil
+			currentNode.methodHandler = new(methodHandler)
+			currentNode.ppath = ""
+			currentNode.isHandler = false
+
+			// Only Static children could reach here
+			currentNode.addStaticChild(n)
+
+			if lcpLen == searchLen {
+				// At parent node
+				currentNode.kind = t
+				currentNode.addHandler(method, h)
+				currentNode.ppath = ppath
+			} else {
+				// Create child node
+				n = newNode(t, search[lcpLen:], currentNode, nil, new(methodHandler), ppath)
+				n.addHandler(method, h)
+				// Only Static children could reach here
+				currentNode.addStaticChild(n)
+			}
+			currentNode.isLeaf = currentNode.staticChildren == nil
+		} else if lcpLen < searchLen {
+			search = search[lcpLen:]
+			c := currentNode.findStaticChild(search[0])
+			if c != nil {
+				// Go deeper
+				currentNode = c
+				continue
+			}
+			// Create child node
+			n := newNode(t, search, currentNode, nil, new(methodHandler), ppath)
+			n.addHandler(method, h)
+			currentNode.addStaticChild(n)
+			currentNode.isLeaf = currentNode.staticChildren == nil
+		} else {
+			// Node already exists
+			if h != nil {
+				currentNode.addHandler(method, h)
+				currentNode.ppath = ppath
+			}
 		}
-		// TODO: else ...
 		return
 	}
 }
 
+func newNode(t kind, pre string, p *node, sc children, mh *methodHandler, ppath string) *node {
+	return &node{
+		kind:           t,
+		label:          pre[0],
+		prefix:         pre,
+		parent:         p,
+		staticChildren: sc,
+		ppath:          ppath,
+		methodHandler:  mh,
+		isLeaf:         sc == nil,
+		isHandler:      mh.isHandler(),
+	}
+}
+
+func (n *node) addStaticChild(c *node) {
+	n.staticChildren = append(n.staticChildren, c)
+}
+
+func (n *node) findStaticChild(l byte) *node {
+	for _, c := range n.staticChildren {
+		if c.label == l {
+			return c
+		}
+	}
+	return nil
+}
+
+func (m *methodHandler) isHandler() bool {
+	return m.connect != nil ||
+		m.delete != nil ||
+		m.get != nil ||
+		m.head != nil ||
+		m.options != nil ||
+		m.patch != nil ||
+		m.post != nil ||
+		m.propfind != nil ||
+		m.put != nil ||
+		m.trace != nil ||
+		m.report != nil
+}
+
+func (m *methodHandler) updateAllowHeader() {
+	buf := new(bytes.Buffer)
+	buf.WriteString(http.MethodOptions)
+
+	for _, method := range methods {
+		if method == http.MethodOptions {
+			continue
+		}
+		if m.find(method) != nil {
+			buf.WriteString(", ")
+			buf.WriteString(method)
+		}
+	}
+	m.allowHeader = buf.String()
+}
+
+func (m *methodHandler) find(method string) HandlerFunc {
+	switch method {
+	case http.MethodConnect:
+		return m.connect
+	case http.MethodDelete:
+		return m.delete
+	case http.MethodGet:
+		return m.get
+	case http.MethodHead:
+		return m.head
+	case http.MethodOptions:
+		return m.options
+	case http.MethodPatch:
+		return m.patch
+	case http.MethodPost:
+		return m.post
+	case PROPFIND:
+		return m.propfind
+	case http.MethodPut:
+		return m.put
+	case http.MethodTrace:
+		return m.trace
+	case REPORT:
+		return m.report
+	default:
+		return nil
+	}
+}
+
 func (n *node) addHandler(method string, h HandlerFunc) {
 	switch method {
+	case http.MethodConnect:
+		n.methodHandler.connect = h
+	case http.MethodDelete:
+		n.methodHandler.delete = h
 	case http.MethodGet:
 		n.methodHandler.get = h
+	case http.MethodHead:
+		n.methodHandler.head = h
+	case http.MethodOptions:
+		n.methodHandler.options = h
+	case http.MethodPatch:
+		n.methodHandler.patch = h
+	case http.MethodPost:
+		n.methodHandler.post = h
+	case PROPFIND:
+		n.methodHandler.propfind = h
+	case http.MethodPut:
+		n.methodHandler.put = h
+	case http.MethodTrace:
+		n.methodHandler.trace = h
+	case REPORT:
+		n.methodHandler.report = h
 	}
 
+	n.methodHandler.updateAllowHeader()
+
 	if h != nil {
 		n.isHandler = true
 	} else {
-		// n.isHandler = n.methodHandler.isHandler()
+		n.isHandler = n.methodHandler.isHandler()
 	}
 }
 
 func (n *node) findHandler(method string) HandlerFunc {
-	switch method {
-	case http.MethodGet:
-		return n.methodHandler.get
-	default:
-		return nil
-	}
+	return n.methodHandler.find(method)
 }
 
 func (n *node) checkMethodNotAllowed() HandlerFunc {
@@ -163,12 +341,11 @@ func (n *node) checkMethodNotAllowed() HandlerFunc {
 
 func (r *Router) Find(method, path string, c Context) {
 	ctx := c.(*context)
-	ctx.path = path
 	currentNode := r.tree // Current node as root
 
 	var (
-		// previousBestMatchNode *node
-		matchedHandler HandlerFunc
+		previousBestMatchNode *node
+		matchedHandler        HandlerFunc
 		// search stores the remaining path to check for match. By each iteration we move from start of path to end of the path
 		// and search value gets shorter and shorter.
 		search      = path
@@ -194,38 +371,49 @@ func (r *Router) Find(method, path string, c Context) {
 			}
 		}
 
+		if lcpLen != prefixLen {
+			// No matching prefix
+			break
+		}
+
 		search = search[lcpLen:]
 		searchIndex = searchIndex + lcpLen
 
-		if search == "" && currentNode.isHandler {
-			// if previousBestMatchNode == nil {
-			// 	previousBestMatchNode = currentNode
-			// }
-			if h := currentNode.findHandler(method); h != nil {
-				matchedHandler = h
-				break
+		if search == "" {
+			if currentNode.isHandler {
+				previousBestMatchNode = currentNode
+				matchedHandler = currentNode.findHandler(method)
 			}
+			break
 		}
 
-		// Not found
-		break
-
+		// Static node
+		child := currentNode.findStaticChild(search[0])
+		if child == nil {
+			// Not found
+			break
+		}
+		currentNode = child
 	}
 
-	// if currentNode == nil && previousBestMatchNode == nil {
-	// 	return // nothing matched at all
-	// }
+	if matchedHandler == nil && previousBestMatchNode == nil {
+		return // nothing matched at all
+	}
 
 	if matchedHandler != nil {
 		ctx.handler = matchedHandler
 	} else {
 		// use previous match as basis. although we have no matching handler we have path match.
 		// so we can send http.StatusMethodNotAllowed (405) instead of http.StatusNotFound (404)
-		// currentNode = previousBestMatchNode
+		currentNode = previousBestMatchNode
 
+		ctx.Set(ContextKeyHeaderAllow, currentNode.methodHandler.allowHeader)
 		ctx.handler = currentNode.checkMethodNotAllowed()
+		if method == http.MethodOptions {
+			ctx.handler = optionsMethodHandler
+		}
 	}
-	// ctx.path = currentNode.ppath
+	ctx.path = currentNode.ppath
 	// ctx.pnames = currentNode.pnames
 
 	return
@@ -26,3 +26,44 @@ func TestRouterStatic(t *testing.T) {
 
 	assert.Equal(t, path, c.Get("path"))
 }
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	e := New()
+	r := e.router
+	r.Add(http.MethodGet, "/users", handlerFunc)
+	r.Add(http.MethodPut, "/users", handlerFunc)
+
+	c := e.NewContext(nil, nil).(*context)
+	r.Find(http.MethodPost, "/users", c)
+	assert.Equal(t, "OPTIONS, GET, PUT", c.Get(ContextKeyHeaderAllow))
+	assert.Equal(t, "/users", c.Path())
+
+	c = e.NewContext(nil, nil).(*context)
+	r.Find(http.MethodPut, "/users", c)
+	assert.NoError(t, c.handler(c))
+	assert.Equal(t, "/users", c.Get("path"))
+}
+
+func TestRouterStatic_multiple(t *testing.T) {
+	e := New()
+	r := e.router
+	paths := []string{"/users", "/users/new", "/user", "/groups", "/"}
+	for _, p := range paths {
+		r.Add(http.MethodGet, p, handlerFunc)
+	}
+
+	for _, p := range paths {
+		c := e.NewContext(nil, nil).(*context)
+		r.Find(http.MethodGet, p, c)
+		if assert.NotNil(t, c.handler, p) {
+			assert.NoError(t, c.handler(c))
+			assert.Equal(t, p, c.Get("path"))
+		}
+	}
+
+	for _, p := range []string{"/use", "/users/", "/users/newer", "/g"} {
+		c := e.NewContext(nil, nil).(*context)
+		r.Find(http.MethodGet, p, c)
+		assert.Equal(t, ErrNotFound, c.handler(c), p)
+	}
+}
@@ -0,0 +1,133 @@
+package echo
+
+import (
+	stdContext "context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultGracefulTimeout is the time `StartConfig` waits for in-flight requests
+// when shutting down, unless `StartConfig.GracefulTimeout` is set.
+const DefaultGracefulTimeout = 10 * time.Second
+
+// StartConfig is the configuration for starting a server which shuts down
+// gracefully when its context is canceled or the process receives SIGINT or
+// SIGTERM.
+type StartConfig struct {
+	// Address to listen on, unless `Echo#Listener` or `Echo#TLSListener` is set.
+	Address string
+
+	// TLSConfig starts an HTTPS server on `Echo#TLSServer` when set. It must
+	// provide certificates unless the server is started with `StartTLS()`.
+	// Optional. Default value nil, starting an HTTP server on `Echo#Server`.
+	TLSConfig *tls.Config
+
+	// ListenerAddrFunc is called with the address the server listens on, once
+	// the listener is created and just before requests are served. Useful with
+	// port ":0" to learn the port chosen.
+	// Optional.
+	ListenerAddrFunc func(addr net.Addr)
+
+	// GracefulTimeout is the time to wait for in-flight requests to finish
+	// when shutting down before connections are closed.
+	// Optional. Default value DefaultGracefulTimeout.
+	GracefulTimeout time.Duration
+}
+
+// Start starts the server and blocks until it is stopped. When ctx is canceled
+// or SIGINT or SIGTERM is received the server is shut down gracefully and nil
+// is returned, or the error the shutdown failed with.
+func (sc StartConfig) Start(ctx stdContext.Context, e *Echo) error {
+	return sc.start(ctx, e, sc.TLSConfig)
+}
+
+// StartTLS is like Start but serves HTTPS with the certificate and key added to
+// `StartConfig.TLSConfig`.
+// If `certFile` or `keyFile` is `string` the values are treated as file paths.
+// If `certFile` or `keyFile` is `[]byte` the values are treated as the certificate or key as-is.
+func (sc StartConfig) StartTLS(ctx stdContext.Context, e *Echo, certFile, keyFile interface{}) error {
+	cert, err := filepathOrContent(certFile)
+	if err != nil {
+		return err
+	}
+	key, err := filepathOrContent(keyFile)
+	if err != nil {
+		return err
+	}
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := new(tls.Config)
+	if sc.TLSConfig != nil {
+		tlsConfig = sc.TLSConfig.Clone()
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, pair)
+	return sc.start(ctx, e, tlsConfig)
+}
+
+func (sc StartConfig) start(ctx stdContext.Context, e *Echo, tlsConfig *tls.Config) error {
+	e.startupMutex.Lock()
+	s := e.Server
+	if tlsConfig != nil {
+		s = e.TLSServer
+		// Cloned as configureTLS appends to NextProtos.
+		s.TLSConfig = tlsConfig.Clone()
+		e.configureTLS(sc.Address)
+	} else {
+		s.Addr = sc.Address
+	}
+	if err := e.configureServer(s); err != nil {
+		e.startupMutex.Unlock()
+		return err
+	}
+	l := e.Listener
+	if tlsConfig != nil {
+		l = e.TLSListener
+	}
+	e.startupMutex.Unlock()
+
+	// Signals are handled before the address is announced, so callers may
+	// send them as soon as ListenerAddrFunc is called.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if sc.ListenerAddrFunc != nil {
+		sc.ListenerAddrFunc(l.Addr())
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Serve(l)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// Stopped by `Echo#Close()` or `Echo#Shutdown()`, or failed.
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := sc.GracefulTimeout
+	if timeout == 0 {
+		timeout = DefaultGracefulTimeout
+	}
+	shutdownCtx, cancel := stdContext.WithTimeout(stdContext.Background(), timeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		s.Close()
+		return err
+	}
+	<-serveErr
+	return nil
+}
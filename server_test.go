@@ -0,0 +1,203 @@
+package echo
+
+import (
+	stdContext "context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startServer runs start in the background and returns the address passed to
+// ListenerAddrFunc and a channel receiving the result of start.
+func startServer(t *testing.T, sc *StartConfig, start func(sc StartConfig) error) (net.Addr, <-chan error) {
+	addrCh := make(chan net.Addr, 1)
+	sc.ListenerAddrFunc = func(addr net.Addr) {
+		addrCh <- addr
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- start(*sc)
+	}()
+
+	select {
+	case addr := <-addrCh:
+		return addr, errCh
+	case err := <-errCh:
+		t.Fatalf("server did not start: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not start")
+	}
+	return nil, nil
+}
+
+func TestStartConfig_Start(t *testing.T) {
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	sc := &StartConfig{Address: "127.0.0.1:0"}
+	addr, errCh := startServer(t, sc, func(sc StartConfig) error {
+		return sc.Start(ctx, e)
+	})
+	assert.Equal(t, addr, e.ListenerAddr())
+
+	res, err := http.Get("http://" + addr.String() + "/")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "OK", string(body))
+	}
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func TestStartConfig_StartTLS(t *testing.T) {
+	cert, key := testCertificate(t)
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, c.Request().Proto)
+	})
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	sc := &StartConfig{Address: "127.0.0.1:0"}
+	addr, errCh := startServer(t, sc, func(sc StartConfig) error {
+		return sc.StartTLS(ctx, e, cert, key)
+	})
+	assert.Equal(t, addr, e.TLSListenerAddr())
+	assert.Nil(t, e.ListenerAddr())
+
+	res, err := tlsClient().Get("https://" + addr.String() + "/")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "HTTP/2.0", string(body))
+	}
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func TestStartConfig_StartTLS_error(t *testing.T) {
+	e := newTestEcho()
+	sc := StartConfig{Address: "127.0.0.1:0"}
+	err := sc.StartTLS(stdContext.Background(), e, 1, 2)
+	assert.Equal(t, ErrInvalidCertOrKeyType, err)
+	assert.Error(t, sc.StartTLS(stdContext.Background(), e, []byte("cert"), []byte("key")))
+}
+
+func TestStartConfig_gracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		close(started)
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	sc := &StartConfig{Address: "127.0.0.1:0"}
+	addr, errCh := startServer(t, sc, func(sc StartConfig) error {
+		return sc.Start(ctx, e)
+	})
+
+	resCh := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + addr.String() + "/")
+		if err != nil {
+			resCh <- err.Error()
+			return
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		resCh <- string(body)
+	}()
+	<-started
+
+	// the in-flight request is served before Start returns
+	cancel()
+	select {
+	case err := <-errCh:
+		t.Fatalf("server stopped before the request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.Equal(t, "done", <-resCh)
+	assert.NoError(t, <-errCh)
+}
+
+func TestStartConfig_gracefulTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	e := newTestEcho()
+	e.GET("/", func(c Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	sc := &StartConfig{Address: "127.0.0.1:0", GracefulTimeout: 50 * time.Millisecond}
+	addr, errCh := startServer(t, sc, func(sc StartConfig) error {
+		return sc.Start(ctx, e)
+	})
+
+	go http.Get("http://" + addr.String() + "/")
+	<-started
+	cancel()
+	assert.Equal(t, stdContext.DeadlineExceeded, <-errCh)
+}
+
+func TestStartConfig_signal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM can not be sent on windows")
+	}
+	e := newTestEcho()
+	sc := &StartConfig{Address: "127.0.0.1:0"}
+	_, errCh := startServer(t, sc, func(sc StartConfig) error {
+		return sc.Start(stdContext.Background(), e)
+	})
+
+	p, err := os.FindProcess(os.Getpid())
+	if assert.NoError(t, err) {
+		assert.NoError(t, p.Signal(syscall.SIGTERM))
+	}
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server was not shut down")
+	}
+}
+
+func TestStartConfig_Close(t *testing.T) {
+	e := newTestEcho()
+	sc := &StartConfig{Address: "127.0.0.1:0"}
+	_, errCh := startServer(t, sc, func(sc StartConfig) error {
+		return sc.Start(stdContext.Background(), e)
+	})
+
+	assert.NoError(t, e.Close())
+	assert.NoError(t, <-errCh)
+}
+
+func TestStartConfig_invalidAddress(t *testing.T) {
+	e := newTestEcho()
+	sc := StartConfig{Address: "127.0.0.1:-1"}
+	assert.Error(t, sc.Start(stdContext.Background(), e))
+}